@@ -0,0 +1,60 @@
+package ethclient
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+type accessListResult struct {
+	AccessList *types.AccessList `json:"accessList"`
+	Error      string            `json:"error,omitempty"`
+	GasUsed    hexutil.Uint64    `json:"gasUsed"`
+}
+
+// CreateAccessList tries to create an access list for a specific transaction based on the
+// current pending state of the blockchain, or the state at blockNumber if it is not nil.
+// vmErr is the execution error of the transaction, the access list is still returned then.
+func (ec *Client) CreateAccessList(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (accessList *types.AccessList, gasUsed uint64, vmErr string, err error) {
+	block := "pending"
+	if blockNumber != nil {
+		block = toBlockNumArg(blockNumber)
+	}
+	var result accessListResult
+	if err = ec.c.CallContext(ctx, &result, "eth_createAccessList", toCallArg(msg), block); err != nil {
+		return nil, 0, "", err
+	}
+	return result.AccessList, uint64(result.GasUsed), result.Error, nil
+}
+
+// SetAttachAccessList configure BuildContractTx to generate an access list for estimated transactions
+// and attach it when it lowers the gas estimate. Legacy chains get an EIP-2930 AccessListTx then.
+func (ec *Client) SetAttachAccessList(attach bool) {
+	ec.attachAccessList = attach
+}
+
+// cheaperAccessList returns the access list of msg and the gas estimate with it if it is lower than gasLimit,
+// otherwise a nil access list and gasLimit
+func (ec *Client) cheaperAccessList(ctx context.Context, msg ethereum.CallMsg, gasLimit uint64) (types.AccessList, uint64, error) {
+	accessList, _, vmErr, err := ec.CreateAccessList(ctx, msg, nil)
+	if err != nil {
+		return nil, 0, errors.WithMessage(err, "create access list")
+	}
+	if vmErr != "" || accessList == nil || len(*accessList) == 0 {
+		return nil, gasLimit, nil
+	}
+
+	msg.AccessList = *accessList
+	gasWithList, err := ec.EstimateGas(ctx, msg)
+	if err != nil {
+		return nil, 0, errors.WithMessage(err, "estimate gas with access list")
+	}
+	if gasWithList >= gasLimit {
+		return nil, gasLimit, nil
+	}
+	return *accessList, gasWithList, nil
+}
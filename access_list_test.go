@@ -0,0 +1,80 @@
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type accessListService struct {
+	accessList types.AccessList
+	gas        uint64
+	gasWith    uint64
+}
+
+func (s *accessListService) CreateAccessList(args map[string]interface{}, block string) accessListResult {
+	return accessListResult{AccessList: &s.accessList, GasUsed: hexutil.Uint64(s.gasWith)}
+}
+
+func (s *accessListService) EstimateGas(args map[string]interface{}) hexutil.Uint64 {
+	if _, ok := args["accessList"]; ok {
+		return hexutil.Uint64(s.gasWith)
+	}
+	return hexutil.Uint64(s.gas)
+}
+
+func TestCheaperAccessList(t *testing.T) {
+	token := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	service := &accessListService{
+		accessList: types.AccessList{{Address: token, StorageKeys: []common.Hash{{1}}}},
+		gas:        50000,
+		gasWith:    48000,
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+
+	msg := ethereum.CallMsg{To: &token, Data: []byte{1}}
+	accessList, gas, err := ec.cheaperAccessList(context.Background(), msg, service.gas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accessList) != 1 || accessList[0].Address != token || gas != 48000 {
+		t.Errorf("access list %v gas %d, want list of %s and 48000", accessList, gas, token)
+	}
+
+	service.gasWith = 51000
+	accessList, gas, err = ec.cheaperAccessList(context.Background(), msg, service.gas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accessList != nil || gas != 50000 {
+		t.Errorf("access list %v gas %d, want no list and 50000", accessList, gas)
+	}
+}
+
+func TestToCallArg(t *testing.T) {
+	to := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	arg := toCallArg(ethereum.CallMsg{
+		To:         &to,
+		GasFeeCap:  big.NewInt(2),
+		GasTipCap:  big.NewInt(1),
+		AccessList: types.AccessList{{Address: to}},
+	}).(map[string]interface{})
+	if arg["maxFeePerGas"].(*hexutil.Big).ToInt().Int64() != 2 || arg["maxPriorityFeePerGas"].(*hexutil.Big).ToInt().Int64() != 1 {
+		t.Errorf("fee caps not passed: %v", arg)
+	}
+	if len(arg["accessList"].(types.AccessList)) != 1 {
+		t.Errorf("access list not passed: %v", arg)
+	}
+}
@@ -0,0 +1,51 @@
+package ethclient
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrAddressInvalidHex    = errors.New("address is not hex")
+	ErrAddressInvalidLength = errors.New("address length is not 20 bytes")
+	ErrAddressChecksum      = errors.New("address has invalid EIP-55 checksum")
+)
+
+// AddressError is returned by ParseAddress for invalid input, Err is one of the ErrAddress errors
+type AddressError struct {
+	Input string
+	Err   error
+}
+
+func (e *AddressError) Error() string {
+	return e.Err.Error() + ": " + e.Input
+}
+
+func (e *AddressError) Unwrap() error {
+	return e.Err
+}
+
+// ParseAddress parse a 0x prefixed hex address strictly. Unlike common.HexToAddress it rejects
+// invalid hex and bad lengths, and mixed case addresses must match their EIP-55 checksum.
+// All lower or all upper case addresses carry no checksum and are accepted.
+func ParseAddress(s string) (common.Address, error) {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return common.Address{}, &AddressError{Input: s, Err: ErrAddressInvalidHex}
+	}
+	hexStr := s[2:]
+	if _, err := hex.DecodeString(hexStr); err != nil && !errors.Is(err, hex.ErrLength) {
+		return common.Address{}, &AddressError{Input: s, Err: ErrAddressInvalidHex}
+	}
+	if len(hexStr) != 2*common.AddressLength {
+		return common.Address{}, &AddressError{Input: s, Err: ErrAddressInvalidLength}
+	}
+
+	address := common.HexToAddress(hexStr)
+	if hexStr != strings.ToLower(hexStr) && hexStr != strings.ToUpper(hexStr) && "0x"+hexStr != address.Hex() {
+		return common.Address{}, &AddressError{Input: s, Err: ErrAddressChecksum}
+	}
+	return address, nil
+}
@@ -0,0 +1,39 @@
+package ethclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", nil},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", nil},
+		{"0X5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", nil},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", ErrAddressChecksum},
+		{"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ErrAddressInvalidHex},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg", ErrAddressInvalidHex},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", ErrAddressInvalidLength},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAe", ErrAddressInvalidLength},
+		{"", ErrAddressInvalidHex},
+	}
+	for _, test := range tests {
+		address, err := ParseAddress(test.input)
+		if !errors.Is(err, test.err) {
+			t.Errorf("ParseAddress(%q) error: %v, want %v", test.input, err, test.err)
+			continue
+		}
+		var addressErr *AddressError
+		if test.err != nil && !errors.As(err, &addressErr) {
+			t.Errorf("ParseAddress(%q) error %T is not *AddressError", test.input, err)
+		}
+		if test.err == nil && address != common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed") {
+			t.Errorf("ParseAddress(%q) = %s", test.input, address)
+		}
+	}
+}
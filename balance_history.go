@@ -0,0 +1,159 @@
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	erc20 "github.com/ackermanx/ethclient/abi"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// balanceBatchSize is the number of balance requests sent in one batch
+const balanceBatchSize = 100
+
+var ErrArchiveRequired = errors.New("historical state not available, an archive node is required")
+
+// BalanceStep is the sampling interval of BalanceHistory, either every Blocks blocks or every Interval.
+// Interval samples are aligned like time.Truncate in UTC, a 24 hour Interval samples the last block
+// before every midnight UTC.
+type BalanceStep struct {
+	Blocks   uint64
+	Interval time.Duration
+}
+
+// BalanceSample is the balance at a block, Time is the sampled time of Interval steps
+type BalanceSample struct {
+	Block   uint64
+	Time    time.Time
+	Balance *big.Int
+}
+
+// BalanceHistory samples the balance of address between fromBlock and toBlock included, token nil is the
+// native currency and otherwise the erc20 token contract. Block steps sample fromBlock, every step after it
+// and toBlock. The balances are requested in batches, ErrArchiveRequired is returned when the node pruned
+// the state of the sampled blocks. The token balance before the contract deployment is zero.
+func (ec *Client) BalanceHistory(ctx context.Context, address common.Address, token *common.Address, fromBlock, toBlock uint64, step BalanceStep) ([]BalanceSample, error) {
+	if fromBlock > toBlock {
+		return nil, errors.Errorf("from block %d after to block %d", fromBlock, toBlock)
+	}
+	samples, err := ec.balanceSamples(ctx, fromBlock, toBlock, step)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		parsedAbi abi.ABI
+		input     []byte
+	)
+	if token != nil {
+		var ok bool
+		if parsedAbi, ok = ec.parsedAbis.Load(*token); !ok {
+			if parsedAbi, err = abi.JSON(strings.NewReader(erc20.ERC20Abi)); err != nil {
+				return nil, errors.WithMessage(err, "parse erc20 abi")
+			}
+			ec.parsedAbis.Store(*token, parsedAbi)
+		}
+		if input, err = parsedAbi.Pack("balanceOf", address); err != nil {
+			return nil, errors.WithMessage(err, "pack balanceOf")
+		}
+	}
+
+	for start := 0; start < len(samples); start += balanceBatchSize {
+		end := start + balanceBatchSize
+		if end > len(samples) {
+			end = len(samples)
+		}
+		reqs := make([]rpc.BatchElem, end-start)
+		balances := make([]hexutil.Big, len(reqs))
+		outputs := make([]hexutil.Bytes, len(reqs))
+		for i := range reqs {
+			block := hexutil.EncodeUint64(samples[start+i].Block)
+			if token == nil {
+				reqs[i] = rpc.BatchElem{Method: "eth_getBalance", Args: []interface{}{address, block}, Result: &balances[i]}
+			} else {
+				msg := ethereum.CallMsg{To: token, Data: input}
+				reqs[i] = rpc.BatchElem{Method: "eth_call", Args: []interface{}{toCallArg(msg), block}, Result: &outputs[i]}
+			}
+		}
+		if err = ec.c.BatchCallContext(ctx, reqs); err != nil {
+			return nil, err
+		}
+
+		for i := range reqs {
+			sample := &samples[start+i]
+			if err = reqs[i].Error; err != nil {
+				if isMissingState(err) {
+					return nil, errors.Wrapf(ErrArchiveRequired, "balance at block %d: %v", sample.Block, err)
+				}
+				return nil, errors.WithMessagef(err, "balance at block %d", sample.Block)
+			}
+			if token == nil {
+				sample.Balance = balances[i].ToInt()
+				continue
+			}
+			if len(outputs[i]) == 0 {
+				sample.Balance = new(big.Int)
+				continue
+			}
+			res, err := parsedAbi.Unpack("balanceOf", outputs[i])
+			if err != nil {
+				return nil, errors.WithMessagef(err, "unpack balanceOf at block %d", sample.Block)
+			}
+			balance, ok := res[0].(*big.Int)
+			if !ok {
+				return nil, errors.New("results[0] is not *big.Int")
+			}
+			sample.Balance = balance
+		}
+	}
+	return samples, nil
+}
+
+// balanceSamples returns the sampled blocks without balances
+func (ec *Client) balanceSamples(ctx context.Context, fromBlock, toBlock uint64, step BalanceStep) ([]BalanceSample, error) {
+	var samples []BalanceSample
+	switch {
+	case step.Interval > 0:
+		from, err := ec.HeaderByNumber(ctx, new(big.Int).SetUint64(fromBlock))
+		if err != nil {
+			return nil, errors.WithMessage(err, "from header")
+		}
+		to, err := ec.HeaderByNumber(ctx, new(big.Int).SetUint64(toBlock))
+		if err != nil {
+			return nil, errors.WithMessage(err, "to header")
+		}
+		t := time.Unix(int64(from.Time), 0).UTC().Truncate(step.Interval)
+		if t.Unix() < int64(from.Time) {
+			t = t.Add(step.Interval)
+		}
+		for ; t.Unix() <= int64(to.Time); t = t.Add(step.Interval) {
+			block, err := ec.BlockNumberAtTime(ctx, t, BlockBefore)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "block at %s", t)
+			}
+			samples = append(samples, BalanceSample{Block: block, Time: t})
+		}
+	case step.Blocks > 0:
+		for block := fromBlock; block < toBlock; block += step.Blocks {
+			samples = append(samples, BalanceSample{Block: block})
+		}
+		samples = append(samples, BalanceSample{Block: toBlock})
+	default:
+		return nil, errors.New("balance step is zero")
+	}
+	return samples, nil
+}
+
+// isMissingState reports whether err means the node does not keep the state of the requested block
+func isMissingState(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "missing trie node") || strings.Contains(msg, "state is not available") ||
+		strings.Contains(msg, "state not available") || strings.Contains(msg, "historical state")
+}
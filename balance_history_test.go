@@ -0,0 +1,97 @@
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	erc20 "github.com/ackermanx/ethclient/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// balanceService keeps the state from block prunedBefore, the balance at block n is n
+type balanceService struct {
+	blockTimeService
+	prunedBefore uint64
+}
+
+func (s *balanceService) balance(block string) (*big.Int, error) {
+	n, err := hexutil.DecodeUint64(block)
+	if err != nil {
+		return nil, err
+	}
+	if n < s.prunedBefore {
+		return nil, errors.New("missing trie node 0000000000000000000000000000000000000000000000000000000000000000 (path )")
+	}
+	return new(big.Int).SetUint64(n), nil
+}
+
+func (s *balanceService) GetBalance(address common.Address, block string) (*hexutil.Big, error) {
+	balance, err := s.balance(block)
+	return (*hexutil.Big)(balance), err
+}
+
+func (s *balanceService) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	balance, err := s.balance(block)
+	if err != nil {
+		return nil, err
+	}
+	parsedAbi, _ := abi.JSON(strings.NewReader(erc20.ERC20Abi))
+	return parsedAbi.Methods["balanceOf"].Outputs.Pack(new(big.Int).Mul(balance, big.NewInt(10)))
+}
+
+func TestBalanceHistory(t *testing.T) {
+	// a block every hour
+	var times []uint64
+	for i := 0; i < 200; i++ {
+		times = append(times, 1000*86400+uint64(i*3600)+60)
+	}
+	service := &balanceService{blockTimeService: blockTimeService{times: times}, prunedBefore: 5}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+	account, token := common.Address{1}, common.Address{2}
+
+	samples, err := ec.BalanceHistory(context.Background(), account, nil, 10, 35, BalanceStep{Blocks: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := []uint64{10, 20, 30, 35}
+	if len(samples) != len(blocks) {
+		t.Fatalf("got %d samples, want %d", len(samples), len(blocks))
+	}
+	for i, sample := range samples {
+		if sample.Block != blocks[i] || sample.Balance.Uint64() != blocks[i] {
+			t.Errorf("sample %d is %+v, want balance %d at block %d", i, sample, blocks[i], blocks[i])
+		}
+	}
+
+	// daily snapshots, midnight falls after blocks 23, 47...
+	samples, err = ec.BalanceHistory(context.Background(), account, &token, 5, 199, BalanceStep{Interval: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 8 {
+		t.Fatalf("got %d daily samples, want 8", len(samples))
+	}
+	for i, sample := range samples {
+		block := uint64(24*i + 23)
+		if sample.Block != block || sample.Balance.Uint64() != 10*block || sample.Time.Unix() != int64(1001+i)*86400 {
+			t.Errorf("daily sample %d is %+v, want balance %d at block %d", i, sample, 10*block, block)
+		}
+	}
+
+	if _, err = ec.BalanceHistory(context.Background(), account, nil, 0, 20, BalanceStep{Blocks: 5}); !errors.Is(err, ErrArchiveRequired) {
+		t.Errorf("pruned state not reported: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package ethclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/pkg/errors"
+)
+
+var ErrBlockIntegrity = errors.New("block integrity check failed")
+
+// headerExtension are the header fields added after go-ethereum v1.10.25, from Shanghai on.
+// types.Header drops them, they are read from the raw block to hash the header.
+type headerExtension struct {
+	WithdrawalsHash  *common.Hash    `json:"withdrawalsRoot"`
+	BlobGasUsed      *hexutil.Uint64 `json:"blobGasUsed"`
+	ExcessBlobGas    *hexutil.Uint64 `json:"excessBlobGas"`
+	ParentBeaconRoot *common.Hash    `json:"parentBeaconBlockRoot"`
+	RequestsHash     *common.Hash    `json:"requestsHash"`
+}
+
+// extendedHeader is the consensus encoding of headers up to Prague, the optional fields are omitted from
+// the end of the list when they are nil
+type extendedHeader struct {
+	ParentHash       common.Hash
+	UncleHash        common.Hash
+	Coinbase         common.Address
+	Root             common.Hash
+	TxHash           common.Hash
+	ReceiptHash      common.Hash
+	Bloom            types.Bloom
+	Difficulty       *big.Int
+	Number           *big.Int
+	GasLimit         uint64
+	GasUsed          uint64
+	Time             uint64
+	Extra            []byte
+	MixDigest        common.Hash
+	Nonce            types.BlockNonce
+	BaseFee          *big.Int     `rlp:"optional"`
+	WithdrawalsHash  *common.Hash `rlp:"optional"`
+	BlobGasUsed      *uint64      `rlp:"optional"`
+	ExcessBlobGas    *uint64      `rlp:"optional"`
+	ParentBeaconRoot *common.Hash `rlp:"optional"`
+	RequestsHash     *common.Hash `rlp:"optional"`
+}
+
+// BlockIntegrityError is returned when a block body does not match its header, it matches ErrBlockIntegrity
+// with errors.Is. Field is the checked part of the block: hash, tx root, uncle hash or uncle.
+type BlockIntegrityError struct {
+	Block    common.Hash
+	Field    string
+	Expected common.Hash
+	Got      common.Hash
+}
+
+func (e *BlockIntegrityError) Error() string {
+	return fmt.Sprintf("%s: block %s %s is %s, want %s", ErrBlockIntegrity, e.Block, e.Field, e.Got, e.Expected)
+}
+
+func (e *BlockIntegrityError) Is(target error) bool {
+	return target == ErrBlockIntegrity
+}
+
+// SetStrictBlocks configure BlockByHash and BlockByNumber to verify the returned blocks: the header hash,
+// including the header fields added since Shanghai, the tx root, the uncle hash and the uncles.
+// Withdrawals are not verified. Blocks with transaction types unknown to go-ethereum v1.10.25, like blob
+// transactions, fail to decode whether strict or not.
+func (ec *Client) SetStrictBlocks(strict bool) {
+	ec.strictBlocks = strict
+}
+
+// VerifyBlock checks that the hash reported by the node is the hash of the block header, and that the
+// transactions and uncles of the block rebuild the TxHash and UncleHash of the header.
+// types.Header drops the header fields added since Shanghai, so the hash of such blocks never matches,
+// the strict client hashes the raw header instead.
+func VerifyBlock(hash common.Hash, block *types.Block) error {
+	return verifyBlock(hash, block.Header().Hash(), block)
+}
+
+func verifyBlock(hash, headerHash common.Hash, block *types.Block) error {
+	header := block.Header()
+	if headerHash != hash {
+		return &BlockIntegrityError{Block: hash, Field: "hash", Expected: hash, Got: headerHash}
+	}
+	if got := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); got != header.TxHash {
+		return &BlockIntegrityError{Block: hash, Field: "tx root", Expected: header.TxHash, Got: got}
+	}
+	if got := types.CalcUncleHash(block.Uncles()); got != header.UncleHash {
+		return &BlockIntegrityError{Block: hash, Field: "uncle hash", Expected: header.UncleHash, Got: got}
+	}
+	return nil
+}
+
+// rawHeaderHash returns the hash of header with the fields of the raw block that types.Header drops
+func rawHeaderHash(header *types.Header, raw json.RawMessage) (common.Hash, error) {
+	var ext headerExtension
+	if err := json.Unmarshal(raw, &ext); err != nil {
+		return common.Hash{}, err
+	}
+	if ext == (headerExtension{}) {
+		return header.Hash(), nil
+	}
+	enc := extendedHeader{
+		ParentHash:       header.ParentHash,
+		UncleHash:        header.UncleHash,
+		Coinbase:         header.Coinbase,
+		Root:             header.Root,
+		TxHash:           header.TxHash,
+		ReceiptHash:      header.ReceiptHash,
+		Bloom:            header.Bloom,
+		Difficulty:       header.Difficulty,
+		Number:           header.Number,
+		GasLimit:         header.GasLimit,
+		GasUsed:          header.GasUsed,
+		Time:             header.Time,
+		Extra:            header.Extra,
+		MixDigest:        header.MixDigest,
+		Nonce:            header.Nonce,
+		BaseFee:          header.BaseFee,
+		WithdrawalsHash:  ext.WithdrawalsHash,
+		BlobGasUsed:      (*uint64)(ext.BlobGasUsed),
+		ExcessBlobGas:    (*uint64)(ext.ExcessBlobGas),
+		ParentBeaconRoot: ext.ParentBeaconRoot,
+		RequestsHash:     ext.RequestsHash,
+	}
+	data, err := rlp.EncodeToBytes(&enc)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
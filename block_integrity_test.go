@@ -0,0 +1,130 @@
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+type blockService struct {
+	header *types.Header
+	hash   common.Hash
+	txs    []*types.Transaction
+	// extra are header fields unknown to types.Header
+	extra map[string]interface{}
+}
+
+func (s *blockService) GetBlockByNumber(number string, full bool) (json.RawMessage, error) {
+	var fields map[string]interface{}
+	data, _ := json.Marshal(s.header)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	fields["hash"] = s.hash
+	fields["transactions"] = s.txs
+	fields["uncles"] = []common.Hash{}
+	for name, value := range s.extra {
+		fields[name] = value
+	}
+	return json.Marshal(fields)
+}
+
+func TestStrictBlocks(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewLondonSigner(big.NewInt(1))
+	var txs []*types.Transaction
+	for i := uint64(0); i < 3; i++ {
+		tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     i,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(10),
+			Gas:       21000,
+			To:        &common.Address{1},
+			Value:     big.NewInt(1),
+		})
+		txs = append(txs, tx)
+	}
+	header := &types.Header{
+		Number:     big.NewInt(10),
+		Difficulty: new(big.Int),
+		UncleHash:  types.EmptyUncleHash,
+		TxHash:     types.DeriveSha(types.Transactions(txs), trie.NewStackTrie(nil)),
+	}
+	service := &blockService{header: header, hash: header.Hash(), txs: txs}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+	ec.SetStrictBlocks(true)
+
+	block, err := ec.BlockByNumber(context.Background(), big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Hash() != service.hash || len(block.Transactions()) != 3 {
+		t.Errorf("unexpected block %s with %d transactions", block.Hash(), len(block.Transactions()))
+	}
+
+	// a node missing a transaction of the block
+	service.txs = txs[:2]
+	var integrityErr *BlockIntegrityError
+	_, err = ec.BlockByNumber(context.Background(), big.NewInt(10))
+	if !errors.Is(err, ErrBlockIntegrity) || !errors.As(err, &integrityErr) || integrityErr.Field != "tx root" {
+		t.Errorf("partial transactions accepted: %v", err)
+	}
+	ec.SetStrictBlocks(false)
+	if _, err = ec.BlockByNumber(context.Background(), big.NewInt(10)); err != nil {
+		t.Errorf("not strict client verified block: %v", err)
+	}
+	ec.SetStrictBlocks(true)
+
+	service.txs = txs
+	service.hash = common.Hash{1}
+	_, err = ec.BlockByNumber(context.Background(), big.NewInt(10))
+	if !errors.As(err, &integrityErr) || integrityErr.Field != "hash" || integrityErr.Got != header.Hash() {
+		t.Errorf("wrong block hash accepted: %v", err)
+	}
+
+	// a post Cancun header, hashed with the fields types.Header drops
+	header.BaseFee = big.NewInt(7)
+	service.extra = map[string]interface{}{
+		"withdrawalsRoot":       types.EmptyRootHash,
+		"blobGasUsed":           "0x20000",
+		"excessBlobGas":         "0x0",
+		"parentBeaconBlockRoot": common.Hash{2},
+	}
+	enc, err := rlp.EncodeToBytes([]interface{}{
+		header.ParentHash, header.UncleHash, header.Coinbase, header.Root, header.TxHash, header.ReceiptHash,
+		header.Bloom, header.Difficulty, header.Number, header.GasLimit, header.GasUsed, header.Time, header.Extra,
+		header.MixDigest, header.Nonce, header.BaseFee, types.EmptyRootHash, uint64(0x20000), uint64(0), common.Hash{2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.hash = crypto.Keccak256Hash(enc)
+	if block, err = ec.BlockByNumber(context.Background(), big.NewInt(10)); err != nil {
+		t.Fatalf("post Cancun block not verified: %v", err)
+	}
+	if len(block.Transactions()) != 3 {
+		t.Errorf("unexpected block with %d transactions", len(block.Transactions()))
+	}
+
+	service.extra["excessBlobGas"] = "0x1"
+	_, err = ec.BlockByNumber(context.Background(), big.NewInt(10))
+	if !errors.As(err, &integrityErr) || integrityErr.Field != "hash" {
+		t.Errorf("altered post Cancun header accepted: %v", err)
+	}
+}
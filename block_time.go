@@ -0,0 +1,164 @@
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// BlockTimeMode selects the block returned by BlockNumberAtTime
+type BlockTimeMode int
+
+const (
+	// BlockBefore is the last block with a timestamp at or before the time
+	BlockBefore BlockTimeMode = iota
+	// BlockAfter is the first block with a timestamp at or after the time
+	BlockAfter
+	// BlockClosest is the block with the timestamp closest to the time, the earlier block on ties
+	BlockClosest
+)
+
+// blockTimeCacheDepth is the number of blocks behind head after which timestamps are cached,
+// blocks closer to head could still be reorged
+const blockTimeCacheDepth = 64
+
+// BlockNumberAtTime returns the number of the block at time t selected by mode. The search interpolates
+// between the timestamps of the bounding blocks, which follows the average block time of the chain,
+// and alternates with bisection so chains with irregular block times still converge in logarithmic steps.
+// The timestamps of the blocks far enough from head are cached in the client, a repeated search only
+// requests the latest header.
+// ethereum.NotFound is returned when no block matches, t before genesis with BlockBefore or t after
+// the latest block with BlockAfter.
+func (ec *Client) BlockNumberAtTime(ctx context.Context, t time.Time, mode BlockTimeMode) (uint64, error) {
+	if t.Unix() < 0 {
+		return 0, errors.Errorf("time %s before unix epoch", t)
+	}
+	target := uint64(t.Unix())
+
+	head, err := ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, errors.WithMessage(err, "latest header")
+	}
+	headNumber := head.Number.Uint64()
+	ec.cacheBlockTime(headNumber, head.Time, headNumber)
+
+	var number uint64
+	switch mode {
+	case BlockBefore:
+		number, err = ec.blockBefore(ctx, head, target)
+	case BlockAfter:
+		number, err = ec.searchBlockTime(ctx, head, target, false)
+		if err == nil && number > headNumber {
+			err = ethereum.NotFound
+		}
+	case BlockClosest:
+		var before, after uint64
+		before, err = ec.blockBefore(ctx, head, target)
+		if errors.Is(err, ethereum.NotFound) {
+			// t is before genesis, the genesis is the closest
+			number, err = 0, nil
+			break
+		} else if err != nil {
+			break
+		}
+		number = before
+		if after = before + 1; after > headNumber {
+			break
+		}
+		var beforeTime, afterTime uint64
+		if beforeTime, err = ec.blockTime(ctx, before, headNumber); err != nil {
+			break
+		}
+		if afterTime, err = ec.blockTime(ctx, after, headNumber); err != nil {
+			break
+		}
+		if afterTime-target < target-beforeTime {
+			number = after
+		}
+	default:
+		return 0, errors.Errorf("unknown block time mode %d", mode)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return number, nil
+}
+
+func (ec *Client) blockBefore(ctx context.Context, head *types.Header, target uint64) (uint64, error) {
+	after, err := ec.searchBlockTime(ctx, head, target, true)
+	if err != nil {
+		return 0, err
+	}
+	if after == 0 {
+		return 0, ethereum.NotFound
+	}
+	return after - 1, nil
+}
+
+// searchBlockTime returns the first block with a timestamp after target, or at target when strict is false.
+// head number + 1 is returned when no block up to head matches.
+func (ec *Client) searchBlockTime(ctx context.Context, head *types.Header, target uint64, strict bool) (uint64, error) {
+	isAfter := func(blockTime uint64) bool {
+		if strict {
+			return blockTime > target
+		}
+		return blockTime >= target
+	}
+	headNumber := head.Number.Uint64()
+	if !isAfter(head.Time) {
+		return headNumber + 1, nil
+	}
+	genesisTime, err := ec.blockTime(ctx, 0, headNumber)
+	if err != nil {
+		return 0, err
+	}
+	if isAfter(genesisTime) {
+		return 0, nil
+	}
+
+	// lo is never after target and hi always is
+	lo, loTime, hi, hiTime := uint64(0), genesisTime, headNumber, head.Time
+	for i := 0; hi-lo > 1; i++ {
+		mid := lo + (hi-lo)/2
+		if i%2 == 0 && hiTime > loTime {
+			mid = lo + (target-loTime)*(hi-lo)/(hiTime-loTime)
+		}
+		if mid <= lo {
+			mid = lo + 1
+		} else if mid >= hi {
+			mid = hi - 1
+		}
+		midTime, err := ec.blockTime(ctx, mid, headNumber)
+		if err != nil {
+			return 0, err
+		}
+		if isAfter(midTime) {
+			hi, hiTime = mid, midTime
+		} else {
+			lo, loTime = mid, midTime
+		}
+	}
+	return hi, nil
+}
+
+func (ec *Client) blockTime(ctx context.Context, number, headNumber uint64) (uint64, error) {
+	if blockTime, ok := ec.blockTimes.Load(number); ok {
+		return blockTime.(uint64), nil
+	}
+	header, err := ec.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return 0, errors.WithMessagef(err, "header %d", number)
+	}
+	ec.cacheBlockTime(number, header.Time, headNumber)
+	return header.Time, nil
+}
+
+func (ec *Client) cacheBlockTime(number, blockTime, headNumber uint64) {
+	if number+blockTimeCacheDepth <= headNumber {
+		ec.blockTimes.Store(number, blockTime)
+	}
+}
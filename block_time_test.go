@@ -0,0 +1,103 @@
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type blockTimeService struct {
+	times []uint64
+	calls int
+}
+
+func (s *blockTimeService) GetBlockByNumber(number string, full bool) (*types.Header, error) {
+	s.calls++
+	n := uint64(len(s.times) - 1)
+	if number != "latest" {
+		var err error
+		if n, err = hexutil.DecodeUint64(number); err != nil {
+			return nil, err
+		}
+	}
+	if n >= uint64(len(s.times)) {
+		return nil, nil
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(n), Difficulty: new(big.Int), Time: s.times[n]}, nil
+}
+
+func TestBlockNumberAtTime(t *testing.T) {
+	// 3 second blocks, then a halt, then several blocks per second like on L2s
+	var times []uint64
+	for i := 0; i < 600; i++ {
+		times = append(times, 1000+uint64(3*i))
+	}
+	times = append(times, 5000)
+	for i := 0; i < 1000; i++ {
+		times = append(times, 5001+uint64(i/4))
+	}
+	service := &blockTimeService{times: times}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+
+	expect := func(target uint64, mode BlockTimeMode) (uint64, bool) {
+		before, after := -1, -1
+		for i, blockTime := range times {
+			if blockTime <= target {
+				before = i
+			}
+			if blockTime >= target && after < 0 {
+				after = i
+			}
+		}
+		switch mode {
+		case BlockBefore:
+			return uint64(before), before >= 0
+		case BlockAfter:
+			return uint64(after), after >= 0
+		}
+		if before < 0 {
+			return uint64(after), true
+		}
+		if after >= 0 && times[after]-target < target-times[before] {
+			return uint64(after), true
+		}
+		return uint64(before), true
+	}
+	for _, target := range []uint64{999, 1000, 1001, 1002, 2000, 2797, 2798, 3500, 5000, 5001, 5100, 5249, 6000} {
+		for _, mode := range []BlockTimeMode{BlockBefore, BlockAfter, BlockClosest} {
+			number, err := ec.BlockNumberAtTime(context.Background(), time.Unix(int64(target), 0), mode)
+			want, found := expect(target, mode)
+			if !found {
+				if !errors.Is(err, ethereum.NotFound) {
+					t.Errorf("time %d mode %d: got %d %v, want not found", target, mode, number, err)
+				}
+				continue
+			}
+			if err != nil || number != want {
+				t.Errorf("time %d mode %d: got %d %v, want %d", target, mode, number, err, want)
+			}
+		}
+	}
+
+	calls := service.calls
+	if _, err := ec.BlockNumberAtTime(context.Background(), time.Unix(2000, 0), BlockBefore); err != nil {
+		t.Fatal(err)
+	}
+	// the timestamps are cached, only the latest header is requested again
+	if service.calls != calls+1 {
+		t.Errorf("repeated lookup made %d calls", service.calls-calls)
+	}
+}
@@ -0,0 +1,141 @@
+// Package chainlink reads chainlink price feeds.
+package chainlink
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	AggregatorV3Abi = `[{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"description","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"version","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"uint80","name":"_roundId","type":"uint80"}],"name":"getRoundData","outputs":[{"internalType":"uint80","name":"roundId","type":"uint80"},{"internalType":"int256","name":"answer","type":"int256"},{"internalType":"uint256","name":"startedAt","type":"uint256"},{"internalType":"uint256","name":"updatedAt","type":"uint256"},{"internalType":"uint80","name":"answeredInRound","type":"uint80"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"latestRoundData","outputs":[{"internalType":"uint80","name":"roundId","type":"uint80"},{"internalType":"int256","name":"answer","type":"int256"},{"internalType":"uint256","name":"startedAt","type":"uint256"},{"internalType":"uint256","name":"updatedAt","type":"uint256"},{"internalType":"uint80","name":"answeredInRound","type":"uint80"}],"stateMutability":"view","type":"function"}]`
+)
+
+var (
+	ErrIncompleteRound = errors.New("chainlink round is incomplete")
+	ErrStaleRound      = errors.New("chainlink round is stale")
+)
+
+// Round is the data of an aggregator round
+type Round struct {
+	RoundID         *big.Int
+	Answer          *big.Int
+	StartedAt       time.Time
+	UpdatedAt       time.Time
+	AnsweredInRound *big.Int
+	// Price is Answer scaled by the feed decimals
+	Price decimal.Decimal
+}
+
+// CheckRound returns ErrIncompleteRound if round has no positive answer or was answered in an earlier round,
+// and ErrStaleRound if round was updated more than heartbeat before now. Zero heartbeat disables the staleness check.
+func CheckRound(round *Round, heartbeat time.Duration, now time.Time) error {
+	if round.UpdatedAt.Unix() <= 0 || round.Answer.Sign() <= 0 {
+		return errors.Wrapf(ErrIncompleteRound, "round %s", round.RoundID)
+	}
+	if round.AnsweredInRound.Cmp(round.RoundID) < 0 {
+		return errors.Wrapf(ErrIncompleteRound, "round %s answered in round %s", round.RoundID, round.AnsweredInRound)
+	}
+	if heartbeat > 0 && now.Sub(round.UpdatedAt) > heartbeat {
+		return errors.Wrapf(ErrStaleRound, "round %s updated at %s, heartbeat %s", round.RoundID, round.UpdatedAt.UTC().Format(time.RFC3339), heartbeat)
+	}
+	return nil
+}
+
+// Feed reads a chainlink aggregator
+type Feed struct {
+	client  *ethclient.Client
+	Address common.Address
+	// Heartbeat is the maximum age of the latest round accepted by LatestPrice, zero accepts any age
+	Heartbeat time.Duration
+
+	mu          sync.Mutex
+	decimals    uint8
+	hasDecimals bool
+}
+
+// NewFeed creates reader of aggregator address, see Feed.Heartbeat
+func NewFeed(client *ethclient.Client, address common.Address, heartbeat time.Duration) *Feed {
+	return &Feed{client: client, Address: address, Heartbeat: heartbeat}
+}
+
+// Decimals returns the decimals of the feed answers, the value is cached after the first call
+func (f *Feed) Decimals(opts *bind.CallOpts) (uint8, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.hasDecimals {
+		return f.decimals, nil
+	}
+
+	var results = make([]interface{}, 0)
+	err := f.client.Call(f.Address, opts, &results, "decimals", AggregatorV3Abi)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "call decimals of feed %s", f.Address)
+	}
+	f.decimals, f.hasDecimals = results[0].(uint8), true
+	return f.decimals, nil
+}
+
+// Description returns the description of the feed such as "ETH / USD"
+func (f *Feed) Description(opts *bind.CallOpts) (string, error) {
+	var results = make([]interface{}, 0)
+	err := f.client.Call(f.Address, opts, &results, "description", AggregatorV3Abi)
+	if err != nil {
+		return "", errors.WithMessagef(err, "call description of feed %s", f.Address)
+	}
+	return results[0].(string), nil
+}
+
+// LatestRoundData read the latest round of the feed, the round is not checked
+func (f *Feed) LatestRoundData(opts *bind.CallOpts) (*Round, error) {
+	return f.roundData(opts, "latestRoundData")
+}
+
+// GetRoundData read round roundID of the feed, the round is not checked
+func (f *Feed) GetRoundData(opts *bind.CallOpts, roundID *big.Int) (*Round, error) {
+	return f.roundData(opts, "getRoundData", roundID)
+}
+
+// LatestPrice read the latest round and returns its price if the round is complete and not older than Heartbeat
+func (f *Feed) LatestPrice(opts *bind.CallOpts) (price decimal.Decimal, err error) {
+	round, err := f.LatestRoundData(opts)
+	if err != nil {
+		return
+	}
+	if err = CheckRound(round, f.Heartbeat, time.Now()); err != nil {
+		err = errors.WithMessagef(err, "feed %s", f.Address)
+		return
+	}
+	return round.Price, nil
+}
+
+func (f *Feed) roundData(opts *bind.CallOpts, method string, params ...interface{}) (*Round, error) {
+	decimals, err := f.Decimals(opts)
+	if err != nil {
+		return nil, err
+	}
+	var results = make([]interface{}, 0)
+	err = f.client.Call(f.Address, opts, &results, method, AggregatorV3Abi, params...)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "call %s of feed %s", method, f.Address)
+	}
+	return newRound(results, decimals), nil
+}
+
+func newRound(results []interface{}, decimals uint8) *Round {
+	answer := results[1].(*big.Int)
+	return &Round{
+		RoundID:         results[0].(*big.Int),
+		Answer:          answer,
+		StartedAt:       time.Unix(results[2].(*big.Int).Int64(), 0),
+		UpdatedAt:       time.Unix(results[3].(*big.Int).Int64(), 0),
+		AnsweredInRound: results[4].(*big.Int),
+		Price:           decimal.NewFromBigInt(answer, -int32(decimals)),
+	}
+}
@@ -0,0 +1,60 @@
+package chainlink
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAbiSelectors(t *testing.T) {
+	aggregatorAbi, err := abi.JSON(strings.NewReader(AggregatorV3Abi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "feaf968c", hex.EncodeToString(aggregatorAbi.Methods["latestRoundData"].ID))
+	assert.Equal(t, "9a6fc8f5", hex.EncodeToString(aggregatorAbi.Methods["getRoundData"].ID))
+	assert.Equal(t, "313ce567", hex.EncodeToString(aggregatorAbi.Methods["decimals"].ID))
+	assert.Equal(t, "7284e416", hex.EncodeToString(aggregatorAbi.Methods["description"].ID))
+
+	registryAbi, err := abi.JSON(strings.NewReader(FeedRegistryAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "d2edb6dd", hex.EncodeToString(registryAbi.Methods["getFeed"].ID))
+}
+
+func TestCheckRound(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	round := newRound([]interface{}{
+		big.NewInt(110), big.NewInt(185012345678), big.NewInt(now.Unix() - 600), big.NewInt(now.Unix() - 600), big.NewInt(110),
+	}, 8)
+	assert.Equal(t, "1850.12345678", round.Price.String())
+	assert.Equal(t, nil, CheckRound(round, time.Hour, now))
+	assert.Equal(t, nil, CheckRound(round, 0, now))
+	assert.ErrorIs(t, CheckRound(round, 5*time.Minute, now), ErrStaleRound)
+
+	round.AnsweredInRound = big.NewInt(109)
+	assert.ErrorIs(t, CheckRound(round, time.Hour, now), ErrIncompleteRound)
+
+	round.AnsweredInRound = big.NewInt(110)
+	round.UpdatedAt = time.Unix(0, 0)
+	assert.ErrorIs(t, CheckRound(round, time.Hour, now), ErrIncompleteRound)
+
+	round.UpdatedAt = now
+	round.Answer = big.NewInt(0)
+	assert.ErrorIs(t, CheckRound(round, time.Hour, now), ErrIncompleteRound)
+}
+
+func TestLookupFeed(t *testing.T) {
+	address, err := LookupFeed(1, "eth / usd")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419", address.Hex())
+
+	_, err = LookupFeed(1, "FOO/USD")
+	assert.ErrorIs(t, err, ErrFeedNotFound)
+}
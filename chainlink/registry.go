@@ -0,0 +1,107 @@
+package chainlink
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FeedRegistryAddr is the chainlink Feed Registry on ethereum mainnet
+	FeedRegistryAddr = "0x47Fb2585D2C56Fe188D0E6ec628a38b74fCeeeDf"
+	FeedRegistryAbi  = `[{"inputs":[{"internalType":"address","name":"base","type":"address"},{"internalType":"address","name":"quote","type":"address"}],"name":"getFeed","outputs":[{"internalType":"contract AggregatorV2V3Interface","name":"aggregator","type":"address"}],"stateMutability":"view","type":"function"}]`
+)
+
+// Feed Registry denominations of assets without token address
+var (
+	DenominationETH = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+	DenominationBTC = common.HexToAddress("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB")
+	DenominationUSD = common.HexToAddress("0x0000000000000000000000000000000000000348")
+)
+
+var ErrFeedNotFound = errors.New("chainlink feed not found")
+
+// FeedAddresses are known aggregator proxies by chain id and pair such as "ETH/USD"
+var FeedAddresses = map[uint64]map[string]common.Address{
+	// ethereum
+	1: {
+		"ETH/USD":  common.HexToAddress("0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419"),
+		"BTC/USD":  common.HexToAddress("0xF4030086522a5bEEa4988F8cA5B36dbC97BeE88c"),
+		"LINK/USD": common.HexToAddress("0x2c1d072e956AFFC0D435Cb7AC38EF18d24d9127c"),
+		"USDC/USD": common.HexToAddress("0x8fFfFfd4AfB6115b954Bd326cbe7B4BA576818f6"),
+		"DAI/USD":  common.HexToAddress("0xAed0c38402a5d19df6E4c03F4E2DceD6e29c1ee9"),
+	},
+	// optimism
+	10: {
+		"ETH/USD": common.HexToAddress("0x13e3Ee699D1909E989722E753853AE30b17e08c5"),
+	},
+	// bsc
+	56: {
+		"BNB/USD": common.HexToAddress("0x0567F2323251f0Aab15c8dFb1967E4e8A7D42aeE"),
+	},
+	// polygon
+	137: {
+		"ETH/USD":   common.HexToAddress("0xF9680D99D6C9589e2a93a78A04A279e509205945"),
+		"MATIC/USD": common.HexToAddress("0xAB594600376Ec9fD91F8e885dADF0CE036862dE0"),
+	},
+	// arbitrum
+	42161: {
+		"ETH/USD": common.HexToAddress("0x639Fe6ab55C921f74e7fac1ee960C0B6293ba612"),
+	},
+}
+
+// LookupFeed returns the aggregator address of pair on chainID from FeedAddresses, pair is case insensitive
+func LookupFeed(chainID uint64, pair string) (common.Address, error) {
+	address, ok := FeedAddresses[chainID][strings.ToUpper(strings.ReplaceAll(pair, " ", ""))]
+	if !ok {
+		return common.Address{}, errors.Wrapf(ErrFeedNotFound, "%s on chain %d", pair, chainID)
+	}
+	return address, nil
+}
+
+// NewFeedByPair creates reader of the known feed of pair on chainID, see LookupFeed
+func NewFeedByPair(client *ethclient.Client, chainID uint64, pair string, heartbeat time.Duration) (*Feed, error) {
+	address, err := LookupFeed(chainID, pair)
+	if err != nil {
+		return nil, err
+	}
+	return NewFeed(client, address, heartbeat), nil
+}
+
+// FeedRegistry resolves feeds through chainlink Feed Registry
+type FeedRegistry struct {
+	client  *ethclient.Client
+	Address common.Address
+}
+
+// NewFeedRegistry creates Feed Registry reader of address, see FeedRegistryAddr
+func NewFeedRegistry(client *ethclient.Client, address common.Address) *FeedRegistry {
+	return &FeedRegistry{client: client, Address: address}
+}
+
+// GetFeed returns the aggregator of base priced in quote, use the Denomination addresses for assets without token
+func (r *FeedRegistry) GetFeed(opts *bind.CallOpts, base, quote common.Address) (common.Address, error) {
+	var results = make([]interface{}, 0)
+	err := r.client.Call(r.Address, opts, &results, "getFeed", FeedRegistryAbi, base, quote)
+	if err != nil {
+		return common.Address{}, errors.WithMessagef(err, "get feed %s/%s", base, quote)
+	}
+	aggregator := results[0].(common.Address)
+	if aggregator == (common.Address{}) {
+		return common.Address{}, errors.Wrapf(ErrFeedNotFound, "%s/%s in registry %s", base, quote, r.Address)
+	}
+	return aggregator, nil
+}
+
+// Feed creates reader of the aggregator of base priced in quote
+func (r *FeedRegistry) Feed(opts *bind.CallOpts, base, quote common.Address, heartbeat time.Duration) (*Feed, error) {
+	aggregator, err := r.GetFeed(opts, base, quote)
+	if err != nil {
+		return nil, err
+	}
+	return NewFeed(r.client, aggregator, heartbeat), nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
 	"time"
 
 	erc20 "github.com/ackermanx/ethclient/abi"
@@ -17,6 +18,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/pkg/errors"
 )
@@ -27,6 +29,15 @@ type Client struct {
 	timeout    int
 	chainID    *big.Int
 	parsedAbis erc20.AddrAbiMap
+	fees       FeeStrategy
+	// attachAccessList attaches generated access lists to contract transactions when they lower gas
+	attachAccessList bool
+	// simulate refuses to sign transactions which fail in eth_call
+	simulate bool
+	// strictBlocks verifies the blocks returned by getBlock against their headers
+	strictBlocks bool
+	// blockTimes caches the block timestamps of BlockNumberAtTime
+	blockTimes sync.Map
 }
 
 // Dial connects a client to the given URL.
@@ -160,7 +171,22 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 		}
 		txs[i] = tx.tx
 	}
-	return types.NewBlockWithHeader(head).WithBody(txs, uncles), nil
+	block := types.NewBlockWithHeader(head).WithBody(txs, uncles)
+	if ec.strictBlocks {
+		headerHash, err := rawHeaderHash(head, raw)
+		if err != nil {
+			return nil, err
+		}
+		for i, uncle := range uncles {
+			if got := uncle.Hash(); got != body.UncleHashes[i] {
+				return nil, &BlockIntegrityError{Block: body.Hash, Field: "uncle", Expected: body.UncleHashes[i], Got: got}
+			}
+		}
+		if err := verifyBlock(body.Hash, headerHash, block); err != nil {
+			return nil, err
+		}
+	}
+	return block, nil
 }
 
 // HeaderByHash returns the block header with the given hash.
@@ -508,6 +534,38 @@ func (ec *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
 	return (*big.Int)(&hex), nil
 }
 
+type feeHistoryResultMarshaling struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory retrieves the fee market history.
+func (ec *Client) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	var res feeHistoryResultMarshaling
+	if err := ec.c.CallContext(ctx, &res, "eth_feeHistory", hexutil.Uint(blockCount), toBlockNumArg(lastBlock), rewardPercentiles); err != nil {
+		return nil, err
+	}
+	reward := make([][]*big.Int, len(res.Reward))
+	for i, r := range res.Reward {
+		reward[i] = make([]*big.Int, len(r))
+		for j, r := range r {
+			reward[i][j] = (*big.Int)(r)
+		}
+	}
+	baseFee := make([]*big.Int, len(res.BaseFee))
+	for i, b := range res.BaseFee {
+		baseFee[i] = (*big.Int)(b)
+	}
+	return &ethereum.FeeHistory{
+		OldestBlock:  (*big.Int)(res.OldestBlock),
+		Reward:       reward,
+		BaseFee:      baseFee,
+		GasUsedRatio: res.GasUsedRatio,
+	}, nil
+}
+
 // EstimateGas tries to estimate the gas needed to execute a specific transaction based on
 // the current pending state of the backend blockchain. There is no guarantee that this is
 // the true gas limit requirement as other transactions may be added or removed by miners,
@@ -538,6 +596,12 @@ func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) er
 // returns, a slice of interfaces for anonymous returns and a struct for named
 // returns.
 func (ec *Client) Call(contractAddr common.Address, opts *bind.CallOpts, results *[]interface{}, method, abiStr string, params ...interface{}) error {
+	return ec.CallWithOverrides(contractAddr, opts, nil, results, method, abiStr, params...)
+}
+
+// CallWithOverrides call contract method like Call with the state and block overridden by overrides,
+// nil overrides is the same as Call
+func (ec *Client) CallWithOverrides(contractAddr common.Address, opts *bind.CallOpts, overrides *CallOverrides, results *[]interface{}, method, abiStr string, params ...interface{}) error {
 	// Don't crash on a lazy user
 	if opts == nil {
 		opts = new(bind.CallOpts)
@@ -566,7 +630,30 @@ func (ec *Client) Call(contractAddr common.Address, opts *bind.CallOpts, results
 		code   []byte
 		output []byte
 	)
-	if opts.Pending {
+	if overrides != nil {
+		blockNumber := opts.BlockNumber
+		if opts.Pending {
+			blockNumber = big.NewInt(-1)
+		}
+		output, err = ec.CallContractWithOverrides(ctx, msg, blockNumber, overrides.State, overrides.Block)
+		if err != nil {
+			return err
+		}
+		if _, ok := overrides.State[contractAddr]; len(output) == 0 && !ok {
+			// Make sure we have a contract to operate on, and bail out otherwise.
+			// The code is read from the same state as the call.
+			if opts.Pending {
+				code, err = ec.PendingCodeAt(ctx, contractAddr)
+			} else {
+				code, err = ec.CodeAt(ctx, contractAddr, opts.BlockNumber)
+			}
+			if err != nil {
+				return err
+			} else if len(code) == 0 {
+				return bind.ErrNoCode
+			}
+		}
+	} else if opts.Pending {
 		output, err = ec.PendingCallContract(ctx, msg)
 		if err == nil && len(output) == 0 {
 			// Make sure we have a contract to operate on, and bail out otherwise.
@@ -603,8 +690,16 @@ func (ec *Client) Call(contractAddr common.Address, opts *bind.CallOpts, results
 // BalanceOf query address in contract balance
 // returns *big.Int and error
 func (ec *Client) BalanceOf(address, contractAddr string) (balance *big.Int, err error) {
+	account, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	contract, err := ParseAddress(contractAddr)
+	if err != nil {
+		return nil, errors.WithMessage(err, "contract")
+	}
 	var results = make([]interface{}, 0)
-	err = ec.Call(common.HexToAddress(contractAddr), nil, &results, "balanceOf", erc20.ERC20Abi, common.HexToAddress(address))
+	err = ec.Call(contract, nil, &results, "balanceOf", erc20.ERC20Abi, account)
 	if err != nil {
 		return nil, err
 	}
@@ -666,45 +761,12 @@ func (ec *Client) BuildContractTx(privKey, method, abiStr string, contract *comm
 	}
 
 	// Figure out reasonable gas price values
-	if opts.GasPrice != nil && (opts.GasFeeCap != nil || opts.GasTipCap != nil) {
-		return nil, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
-	}
-	head, err := ec.HeaderByNumber(ensureContext(opts.Context), nil)
-	if err != nil {
-		return nil, errors.WithMessage(err, "header by number")
-	}
-	if head.BaseFee != nil && opts.GasPrice == nil {
-		if opts.GasTipCap == nil {
-			tip, err := ec.SuggestGasTipCap(ensureContext(opts.Context))
-			if err != nil {
-				return nil, err
-			}
-			opts.GasTipCap = tip
-		}
-		if opts.GasFeeCap == nil {
-			gasFeeCap := new(big.Int).Add(
-				opts.GasTipCap,
-				new(big.Int).Mul(head.BaseFee, big.NewInt(2)),
-			)
-			opts.GasFeeCap = gasFeeCap
-		}
-		if opts.GasFeeCap.Cmp(opts.GasTipCap) < 0 {
-			return nil, fmt.Errorf("maxFeePerGas (%v) < maxPriorityFeePerGas (%v)", opts.GasFeeCap, opts.GasTipCap)
-		}
-	} else {
-		if opts.GasFeeCap != nil || opts.GasTipCap != nil {
-			return nil, errors.New("maxFeePerGas or maxPriorityFeePerGas specified but london is not active yet")
-		}
-		if opts.GasPrice == nil {
-			price, err := ec.SuggestGasPrice(ensureContext(opts.Context))
-			if err != nil {
-				return nil, err
-			}
-			opts.GasPrice = price
-		}
+	if err = ec.setGasPrice(ensureContext(opts.Context), opts); err != nil {
+		return nil, err
 	}
 
 	gasLimit := opts.GasLimit
+	var accessList types.AccessList
 	if gasLimit == 0 {
 		// Gas estimation cannot succeed without code for method invocations
 		if code, err := ec.PendingCodeAt(ensureContext(opts.Context), *contract); err != nil {
@@ -713,16 +775,40 @@ func (ec *Client) BuildContractTx(privKey, method, abiStr string, contract *comm
 			return nil, bind.ErrNoCode
 		}
 		// If the contract surely has code (or code is not needed), estimate the transaction
-		msg := ethereum.CallMsg{From: opts.From, To: contract, GasPrice: opts.GasPrice, GasTipCap: opts.GasTipCap, GasFeeCap: opts.GasFeeCap, Value: value, Data: input}
+		msg := ethereum.CallMsg{From: from, To: contract, GasPrice: opts.GasPrice, GasTipCap: opts.GasTipCap, GasFeeCap: opts.GasFeeCap, Value: value, Data: input}
 		gasLimit, err = ec.EstimateGas(ensureContext(opts.Context), msg)
 		if err != nil {
 			return nil, fmt.Errorf("failed to estimate gas needed: %v", err)
 		}
+		if ec.attachAccessList {
+			accessList, gasLimit, err = ec.cheaperAccessList(ensureContext(opts.Context), msg, gasLimit)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if ec.simulate {
+		msg := ethereum.CallMsg{From: from, To: contract, Gas: gasLimit, GasPrice: opts.GasPrice, GasTipCap: opts.GasTipCap, GasFeeCap: opts.GasFeeCap, Value: value, Data: input, AccessList: accessList}
+		// an estimated gas limit is the gas needed, only the limit of the caller is checked
+		if err = ec.simulateTx(ensureContext(opts.Context), msg, opts.GasLimit != 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if ec.chainID == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(ec.timeout))
+		chainID, err := ec.ChainID(ctx)
+		cancel()
+		if err != nil {
+			return nil, errors.WithMessage(err, "get chain id: ")
+		}
+		ec.chainID = chainID
 	}
 
 	// Create the transaction, sign it and schedule it for execution
 	var rawTx *types.Transaction
-	if opts.GasFeeCap == nil {
+	if opts.GasFeeCap == nil && accessList == nil {
 		baseTx := &types.LegacyTx{
 			Nonce:    nonce,
 			GasPrice: opts.GasPrice,
@@ -732,29 +818,32 @@ func (ec *Client) BuildContractTx(privKey, method, abiStr string, contract *comm
 			Data:     input,
 		}
 		rawTx = types.NewTx(baseTx)
+	} else if opts.GasFeeCap == nil {
+		baseTx := &types.AccessListTx{
+			ChainID:    ec.chainID,
+			Nonce:      nonce,
+			GasPrice:   opts.GasPrice,
+			Gas:        gasLimit,
+			To:         contract,
+			Value:      value,
+			Data:       input,
+			AccessList: accessList,
+		}
+		rawTx = types.NewTx(baseTx)
 	} else {
 		baseTx := &types.DynamicFeeTx{
-			Nonce:     nonce,
-			GasFeeCap: opts.GasFeeCap,
-			GasTipCap: opts.GasTipCap,
-			Gas:       gasLimit,
-			Value:     value,
-			To:        contract,
-			Data:      input,
+			Nonce:      nonce,
+			GasFeeCap:  opts.GasFeeCap,
+			GasTipCap:  opts.GasTipCap,
+			Gas:        gasLimit,
+			Value:      value,
+			To:         contract,
+			Data:       input,
+			AccessList: accessList,
 		}
 		rawTx = types.NewTx(baseTx)
 	}
 
-	if ec.chainID == nil {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(ec.timeout))
-		chainID, err := ec.ChainID(ctx)
-		cancel()
-		if err != nil {
-			return nil, errors.WithMessage(err, "get chain id: ")
-		}
-		ec.chainID = chainID
-	}
-
 	signedTx, err := types.SignTx(rawTx, types.NewLondonSigner(ec.chainID), pKey)
 	if err != nil {
 		err = errors.WithMessage(err, "signed raw tx")
@@ -764,6 +853,10 @@ func (ec *Client) BuildContractTx(privKey, method, abiStr string, contract *comm
 }
 
 func (ec *Client) BuildTransferTx(privKey, to string, opts *bind.TransactOpts) (tx *types.Transaction, err error) {
+	toAddr, err := ParseAddress(to)
+	if err != nil {
+		return nil, err
+	}
 	// decode private key
 	pKey, err := crypto.HexToECDSA(privKey)
 	if err != nil {
@@ -774,7 +867,12 @@ func (ec *Client) BuildTransferTx(privKey, to string, opts *bind.TransactOpts) (
 
 	// Don't crash on a lazy user
 	if opts == nil {
-		opts = &bind.TransactOpts{From: from, GasLimit: 21000}
+		opts = &bind.TransactOpts{From: from, GasLimit: params.TxGas}
+	}
+	if opts.GasLimit == 0 {
+		opts.GasLimit = params.TxGas
+	} else if opts.GasLimit < params.TxGas {
+		return nil, errors.Errorf("gas limit %d is below the %d intrinsic gas of a transfer", opts.GasLimit, params.TxGas)
 	}
 
 	// Ensure a valid value field and resolve the account nonce
@@ -793,44 +891,15 @@ func (ec *Client) BuildTransferTx(privKey, to string, opts *bind.TransactOpts) (
 	}
 
 	// Figure out reasonable gas price values
-	if opts.GasPrice != nil && (opts.GasFeeCap != nil || opts.GasTipCap != nil) {
-		return nil, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
-	}
-	head, err := ec.HeaderByNumber(ensureContext(opts.Context), nil)
-	if err != nil {
-		return nil, errors.WithMessage(err, "header by number")
+	if err = ec.setGasPrice(ensureContext(opts.Context), opts); err != nil {
+		return nil, err
 	}
-	if head.BaseFee != nil && opts.GasPrice == nil {
-		if opts.GasTipCap == nil {
-			tip, err := ec.SuggestGasTipCap(ensureContext(opts.Context))
-			if err != nil {
-				return nil, err
-			}
-			opts.GasTipCap = tip
-		}
-		if opts.GasFeeCap == nil {
-			gasFeeCap := new(big.Int).Add(
-				opts.GasTipCap,
-				new(big.Int).Mul(head.BaseFee, big.NewInt(2)),
-			)
-			opts.GasFeeCap = gasFeeCap
-		}
-		if opts.GasFeeCap.Cmp(opts.GasTipCap) < 0 {
-			return nil, fmt.Errorf("maxFeePerGas (%v) < maxPriorityFeePerGas (%v)", opts.GasFeeCap, opts.GasTipCap)
-		}
-	} else {
-		if opts.GasFeeCap != nil || opts.GasTipCap != nil {
-			return nil, errors.New("maxFeePerGas or maxPriorityFeePerGas specified but london is not active yet")
-		}
-		if opts.GasPrice == nil {
-			price, err := ec.SuggestGasPrice(ensureContext(opts.Context))
-			if err != nil {
-				return nil, err
-			}
-			opts.GasPrice = price
+	if ec.simulate {
+		msg := ethereum.CallMsg{From: from, To: &toAddr, Gas: opts.GasLimit, GasPrice: opts.GasPrice, GasTipCap: opts.GasTipCap, GasFeeCap: opts.GasFeeCap, Value: opts.Value}
+		if err = ec.simulateTx(ensureContext(opts.Context), msg, true); err != nil {
+			return nil, err
 		}
 	}
-	toAddr := common.HexToAddress(to)
 	// Create the transaction, sign it and schedule it for execution
 	var rawTx *types.Transaction
 	if opts.GasFeeCap == nil {
@@ -891,6 +960,15 @@ func toCallArg(msg ethereum.CallMsg) interface{} {
 	if msg.GasPrice != nil {
 		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
 	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*hexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*hexutil.Big)(msg.GasTipCap)
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
 	return arg
 }
 
@@ -0,0 +1,186 @@
+// Package ens resolves ENS names.
+package ens
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// RegistryAddr is the ENS registry, deployed at the same address on ethereum mainnet and testnets
+	RegistryAddr = "0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"
+	// Abi contains the resolver method of the ENS registry and the addr, name and text methods of public resolvers
+	Abi = `[{"inputs":[{"internalType":"bytes32","name":"node","type":"bytes32"}],"name":"resolver","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"bytes32","name":"node","type":"bytes32"}],"name":"addr","outputs":[{"internalType":"address payable","name":"","type":"address"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"bytes32","name":"node","type":"bytes32"}],"name":"name","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"bytes32","name":"node","type":"bytes32"},{"internalType":"string","name":"key","type":"string"}],"name":"text","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"}]`
+
+	reverseSuffix = "addr.reverse"
+)
+
+var (
+	ErrInvalidName     = errors.New("invalid ens name")
+	ErrNoResolver      = errors.New("ens name has no resolver")
+	ErrNameNotFound    = errors.New("ens name not resolved")
+	ErrReverseMismatch = errors.New("ens reverse record does not resolve back to the address")
+	ErrInvalidAddress  = errors.New("neither hex address nor ens name")
+)
+
+// Normalize lower case name and apply NFC, labels must be non empty and must not contain
+// whitespace, control or punctuation characters other than '-' and '_'.
+// This covers the common names, not the whole ENSIP-15 normalization.
+func Normalize(name string) (string, error) {
+	normalized := norm.NFC.String(strings.ToLower(strings.TrimSpace(name)))
+	if normalized == "" {
+		return "", errors.Wrap(ErrInvalidName, "empty name")
+	}
+	for _, label := range strings.Split(normalized, ".") {
+		if label == "" {
+			return "", errors.Wrapf(ErrInvalidName, "%q has an empty label", name)
+		}
+		for _, r := range label {
+			if r == '-' || r == '_' {
+				continue
+			}
+			if unicode.IsSpace(r) || unicode.IsControl(r) || unicode.IsPunct(r) || (unicode.IsSymbol(r) && r < unicode.MaxASCII) {
+				return "", errors.Wrapf(ErrInvalidName, "%q contains %q", name, r)
+			}
+		}
+	}
+	return normalized, nil
+}
+
+// NameHash normalize name and calculate its EIP-137 namehash
+func NameHash(name string) (node common.Hash, err error) {
+	normalized, err := Normalize(name)
+	if err != nil {
+		return
+	}
+	labels := strings.Split(normalized, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node.Bytes(), crypto.Keccak256([]byte(labels[i])))
+	}
+	return
+}
+
+// ReverseName returns the reverse record name of address, such as <hex address>.addr.reverse
+func ReverseName(address common.Address) string {
+	return strings.ToLower(address.Hex()[2:]) + "." + reverseSuffix
+}
+
+// ENS resolves names through an ENS registry
+type ENS struct {
+	client   *ethclient.Client
+	Registry common.Address
+}
+
+// New creates ENS resolver using the registry at RegistryAddr
+func New(client *ethclient.Client) *ENS {
+	return NewWithRegistry(client, common.HexToAddress(RegistryAddr))
+}
+
+// NewWithRegistry creates ENS resolver using registry
+func NewWithRegistry(client *ethclient.Client, registry common.Address) *ENS {
+	return &ENS{client: client, Registry: registry}
+}
+
+// Resolver returns the resolver of name
+func (e *ENS) Resolver(opts *bind.CallOpts, name string) (common.Address, error) {
+	node, err := NameHash(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return e.resolver(opts, name, node)
+}
+
+func (e *ENS) resolver(opts *bind.CallOpts, name string, node common.Hash) (common.Address, error) {
+	var results = make([]interface{}, 0)
+	err := e.client.Call(e.Registry, opts, &results, "resolver", Abi, node)
+	if err != nil {
+		return common.Address{}, errors.WithMessagef(err, "get resolver of %s", name)
+	}
+	resolver := results[0].(common.Address)
+	if resolver == (common.Address{}) {
+		return common.Address{}, errors.Wrap(ErrNoResolver, name)
+	}
+	return resolver, nil
+}
+
+// call look up the resolver of name and call method of it with the namehash followed by params
+func (e *ENS) call(opts *bind.CallOpts, name, method string, params ...interface{}) (result interface{}, err error) {
+	node, err := NameHash(name)
+	if err != nil {
+		return
+	}
+	resolver, err := e.resolver(opts, name, node)
+	if err != nil {
+		return
+	}
+	var results = make([]interface{}, 0)
+	err = e.client.Call(resolver, opts, &results, method, Abi, append([]interface{}{node}, params...)...)
+	if err != nil {
+		err = errors.WithMessagef(err, "call %s of %s", method, name)
+		return
+	}
+	return results[0], nil
+}
+
+// Resolve returns the address of name
+func (e *ENS) Resolve(opts *bind.CallOpts, name string) (common.Address, error) {
+	result, err := e.call(opts, name, "addr")
+	if err != nil {
+		return common.Address{}, err
+	}
+	address := result.(common.Address)
+	if address == (common.Address{}) {
+		return common.Address{}, errors.Wrap(ErrNameNotFound, name)
+	}
+	return address, nil
+}
+
+// ReverseResolve returns the primary name of address. The name must resolve back to address,
+// anyone can set any name as reverse record of their own address.
+func (e *ENS) ReverseResolve(opts *bind.CallOpts, address common.Address) (string, error) {
+	result, err := e.call(opts, ReverseName(address), "name")
+	if err != nil {
+		return "", err
+	}
+	name := result.(string)
+	if name == "" {
+		return "", errors.Wrapf(ErrNameNotFound, "reverse record of %s", address)
+	}
+	resolved, err := e.Resolve(opts, name)
+	if err != nil {
+		return "", err
+	}
+	if resolved != address {
+		return "", errors.Wrapf(ErrReverseMismatch, "%s resolves to %s instead of %s", name, resolved, address)
+	}
+	return name, nil
+}
+
+// Text returns the text record key of name, such as "url", "avatar" or "com.twitter"
+func (e *ENS) Text(opts *bind.CallOpts, name, key string) (string, error) {
+	result, err := e.call(opts, name, "text", key)
+	if err != nil {
+		return "", err
+	}
+	return result.(string), nil
+}
+
+// ParseAddress returns the address of s which is either a 0x prefixed hex address, parsed by
+// ethclient.ParseAddress, or an ENS name
+func (e *ENS) ParseAddress(opts *bind.CallOpts, s string) (common.Address, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return ethclient.ParseAddress(s)
+	}
+	if !strings.Contains(s, ".") {
+		return common.Address{}, errors.Wrap(ErrInvalidAddress, s)
+	}
+	return e.Resolve(opts, s)
+}
@@ -0,0 +1,36 @@
+package ens
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameHash(t *testing.T) {
+	testCases := []struct {
+		name string
+		node string
+	}{
+		{"eth", "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae"},
+		{"foo.eth", "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f"},
+		{"Foo.ETH", "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f"},
+	}
+	for _, tc := range testCases {
+		node, err := NameHash(tc.name)
+		if !assert.Equal(t, nil, err, tc.name) {
+			continue
+		}
+		assert.Equal(t, tc.node, node.Hex(), tc.name)
+	}
+
+	for _, name := range []string{"", "foo..eth", ".eth", "foo bar.eth", "foo$.eth"} {
+		_, err := NameHash(name)
+		assert.ErrorIs(t, err, ErrInvalidName, name)
+	}
+}
+
+func TestReverseName(t *testing.T) {
+	address := common.HexToAddress("0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045")
+	assert.Equal(t, "d8da6bf26964af9d7eed9e03e53415d37aa96045.addr.reverse", ReverseName(address))
+}
@@ -0,0 +1,186 @@
+package ethclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pkg/errors"
+)
+
+// FeeStrategy suggests the fees of EIP-1559 transactions built by BuildContractTx and BuildTransferTx
+type FeeStrategy interface {
+	// SuggestFees returns the gasTipCap and gasFeeCap of a transaction included after head
+	SuggestFees(ctx context.Context, ec *Client, head *types.Header) (gasTipCap, gasFeeCap *big.Int, err error)
+}
+
+// NodeFeeStrategy takes the tip suggested by the node and allows the base fee to double,
+// it is the strategy used when none is configured
+type NodeFeeStrategy struct{}
+
+func (NodeFeeStrategy) SuggestFees(ctx context.Context, ec *Client, head *types.Header) (gasTipCap, gasFeeCap *big.Int, err error) {
+	gasTipCap, err = ec.SuggestGasTipCap(ctx)
+	if err != nil {
+		return
+	}
+	gasFeeCap = new(big.Int).Add(gasTipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	return
+}
+
+// PercentileFeeStrategy suggests fees from eth_feeHistory. The tip is the median over Blocks blocks of the
+// Percentile reward of each block, and the fee cap covers the base fee rising at full blocks for BaseFeeBlocks blocks.
+type PercentileFeeStrategy struct {
+	// Blocks is the number of recent blocks the reward is sampled from
+	Blocks uint64
+	// Percentile is the reward percentile of the transactions of each block, between 0 and 100
+	Percentile float64
+	// BaseFeeBlocks is the number of blocks the transaction can wait with rising base fee
+	BaseFeeBlocks int
+	// MinTipCap, MaxTipCap and MaxFeeCap bound the suggested fees when not nil
+	MinTipCap *big.Int
+	MaxTipCap *big.Int
+	MaxFeeCap *big.Int
+}
+
+// Fee strategy presets, copy and adjust the caps for chains with different fee levels
+var (
+	FeeStrategySlow = &PercentileFeeStrategy{
+		Blocks:        20,
+		Percentile:    10,
+		BaseFeeBlocks: 2,
+		MaxTipCap:     big.NewInt(500 * params.GWei),
+	}
+	FeeStrategyNormal = &PercentileFeeStrategy{
+		Blocks:        20,
+		Percentile:    50,
+		BaseFeeBlocks: 3,
+		MaxTipCap:     big.NewInt(500 * params.GWei),
+	}
+	FeeStrategyFast = &PercentileFeeStrategy{
+		Blocks:        20,
+		Percentile:    90,
+		BaseFeeBlocks: 6,
+		MaxTipCap:     big.NewInt(500 * params.GWei),
+	}
+)
+
+func (s *PercentileFeeStrategy) SuggestFees(ctx context.Context, ec *Client, head *types.Header) (gasTipCap, gasFeeCap *big.Int, err error) {
+	if s.Blocks == 0 {
+		return nil, nil, errors.New("fee history blocks is zero")
+	}
+	history, err := ec.FeeHistory(ctx, s.Blocks, head.Number, []float64{s.Percentile})
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "fee history")
+	}
+
+	rewards := make([]*big.Int, 0, len(history.Reward))
+	for i, reward := range history.Reward {
+		// empty blocks report zero rewards and would drag the tip down
+		if len(reward) == 0 || (i < len(history.GasUsedRatio) && history.GasUsedRatio[i] == 0) {
+			continue
+		}
+		rewards = append(rewards, reward[0])
+	}
+	gasTipCap = new(big.Int)
+	if len(rewards) > 0 {
+		sort.Slice(rewards, func(i, j int) bool { return rewards[i].Cmp(rewards[j]) < 0 })
+		gasTipCap.Set(rewards[len(rewards)/2])
+	}
+	if s.MinTipCap != nil && gasTipCap.Cmp(s.MinTipCap) < 0 {
+		gasTipCap.Set(s.MinTipCap)
+	}
+	if s.MaxTipCap != nil && gasTipCap.Cmp(s.MaxTipCap) > 0 {
+		gasTipCap.Set(s.MaxTipCap)
+	}
+
+	// the last base fee of the history is the base fee of the block after head
+	baseFee := head.BaseFee
+	if len(history.BaseFee) > 0 {
+		baseFee = history.BaseFee[len(history.BaseFee)-1]
+	}
+	gasFeeCap = new(big.Int).Add(ProjectBaseFee(baseFee, s.BaseFeeBlocks), gasTipCap)
+	if s.MaxFeeCap != nil && gasFeeCap.Cmp(s.MaxFeeCap) > 0 {
+		gasFeeCap.Set(s.MaxFeeCap)
+		if gasTipCap.Cmp(gasFeeCap) > 0 {
+			gasTipCap.Set(gasFeeCap)
+		}
+	}
+	return
+}
+
+// ProjectBaseFee returns the base fee after blocks full blocks, the base fee rises by 12.5% per full block
+func ProjectBaseFee(baseFee *big.Int, blocks int) *big.Int {
+	projected := new(big.Int).Set(baseFee)
+	for i := 0; i < blocks; i++ {
+		delta := new(big.Int).Div(projected, big.NewInt(params.BaseFeeChangeDenominator))
+		if delta.Sign() == 0 {
+			delta.SetInt64(1)
+		}
+		projected.Add(projected, delta)
+	}
+	return projected
+}
+
+// SetFeeStrategy configure the fee strategy of the transaction builders, nil restores NodeFeeStrategy
+func (ec *Client) SetFeeStrategy(strategy FeeStrategy) {
+	ec.fees = strategy
+}
+
+func (ec *Client) feeStrategy() FeeStrategy {
+	if ec.fees == nil {
+		return NodeFeeStrategy{}
+	}
+	return ec.fees
+}
+
+// setGasPrice fills the gas price of opts, or the missing fee caps on EIP-1559 chains. The fee cap is
+// derived from the final tip plus the base fee headroom of the fee strategy, so a tip set by the caller
+// keeps the headroom of the strategy.
+func (ec *Client) setGasPrice(ctx context.Context, opts *bind.TransactOpts) error {
+	if opts.GasPrice != nil && (opts.GasFeeCap != nil || opts.GasTipCap != nil) {
+		return errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	head, err := ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return errors.WithMessage(err, "header by number")
+	}
+	if head.BaseFee == nil || opts.GasPrice != nil {
+		if opts.GasFeeCap != nil || opts.GasTipCap != nil {
+			return errors.New("maxFeePerGas or maxPriorityFeePerGas specified but london is not active yet")
+		}
+		if opts.GasPrice == nil {
+			price, err := ec.SuggestGasPrice(ctx)
+			if err != nil {
+				return err
+			}
+			opts.GasPrice = price
+		}
+		return nil
+	}
+
+	if opts.GasTipCap == nil || opts.GasFeeCap == nil {
+		tip, feeCap, err := ec.feeStrategy().SuggestFees(ctx, ec, head)
+		if err != nil {
+			return errors.WithMessage(err, "suggest fees")
+		}
+		// the transaction is not includable below the current base fee
+		headroom := new(big.Int).Sub(feeCap, tip)
+		if headroom.Cmp(head.BaseFee) < 0 {
+			headroom.Set(head.BaseFee)
+		}
+		if opts.GasTipCap == nil {
+			opts.GasTipCap = tip
+		}
+		if opts.GasFeeCap == nil {
+			opts.GasFeeCap = headroom.Add(headroom, opts.GasTipCap)
+		}
+	}
+	if opts.GasFeeCap.Cmp(opts.GasTipCap) < 0 {
+		return fmt.Errorf("maxFeePerGas (%v) < maxPriorityFeePerGas (%v)", opts.GasFeeCap, opts.GasTipCap)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type feeHistoryService struct {
+	result feeHistoryResultMarshaling
+}
+
+func (s *feeHistoryService) FeeHistory(blockCount hexutil.Uint, lastBlock string, percentiles []float64) feeHistoryResultMarshaling {
+	return s.result
+}
+
+func TestPercentileFeeStrategy(t *testing.T) {
+	gwei := func(v int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(v * 1e9)) }
+	service := &feeHistoryService{result: feeHistoryResultMarshaling{
+		OldestBlock:  (*hexutil.Big)(big.NewInt(100)),
+		Reward:       [][]*hexutil.Big{{gwei(3)}, {gwei(0)}, {gwei(1)}, {gwei(2)}},
+		BaseFee:      []*hexutil.Big{gwei(10), gwei(10), gwei(10), gwei(10), gwei(16)},
+		GasUsedRatio: []float64{0.5, 0, 0.5, 0.5},
+	}}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+	head := &types.Header{Number: big.NewInt(103), BaseFee: big.NewInt(10e9)}
+
+	// the empty block is skipped, the median of 1, 2 and 3 gwei is 2 gwei
+	tip, feeCap, err := (&PercentileFeeStrategy{Blocks: 4, Percentile: 50, BaseFeeBlocks: 2}).SuggestFees(context.Background(), ec, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip.Cmp(big.NewInt(2e9)) != 0 {
+		t.Errorf("tip = %s, want 2 gwei", tip)
+	}
+	// 16 gwei base fee after two full blocks is 20.25 gwei
+	if feeCap.Cmp(big.NewInt(22.25e9)) != 0 {
+		t.Errorf("fee cap = %s, want 22.25 gwei", feeCap)
+	}
+
+	tip, feeCap, err = (&PercentileFeeStrategy{
+		Blocks:    4,
+		MinTipCap: big.NewInt(5e9),
+		MaxFeeCap: big.NewInt(18e9),
+	}).SuggestFees(context.Background(), ec, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip.Cmp(big.NewInt(5e9)) != 0 || feeCap.Cmp(big.NewInt(18e9)) != 0 {
+		t.Errorf("tip = %s fee cap = %s, want 5 and 18 gwei", tip, feeCap)
+	}
+}
+
+func TestProjectBaseFee(t *testing.T) {
+	if got := ProjectBaseFee(big.NewInt(800), 3); got.Int64() != 1138 {
+		t.Errorf("ProjectBaseFee = %s, want 1138", got)
+	}
+	if got := ProjectBaseFee(big.NewInt(1), 2); got.Int64() != 3 {
+		t.Errorf("ProjectBaseFee = %s, want 3", got)
+	}
+}
+
+type gasPriceService struct {
+	baseFee *big.Int
+	tip     *big.Int
+}
+
+func (s *gasPriceService) GetBlockByNumber(number string, full bool) *types.Header {
+	return &types.Header{Number: big.NewInt(1), Difficulty: new(big.Int), BaseFee: s.baseFee}
+}
+
+func (s *gasPriceService) MaxPriorityFeePerGas() *hexutil.Big {
+	return (*hexutil.Big)(s.tip)
+}
+
+func TestSetGasPrice(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", &gasPriceService{baseFee: big.NewInt(10e9), tip: big.NewInt(1e9)}); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+
+	opts := &bind.TransactOpts{}
+	if err := ec.setGasPrice(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.GasTipCap.Cmp(big.NewInt(1e9)) != 0 || opts.GasFeeCap.Cmp(big.NewInt(21e9)) != 0 {
+		t.Errorf("tip = %s fee cap = %s, want 1 and 21 gwei", opts.GasTipCap, opts.GasFeeCap)
+	}
+
+	// a caller tip above the suggested tip keeps the base fee headroom
+	opts = &bind.TransactOpts{GasTipCap: big.NewInt(50e9)}
+	if err := ec.setGasPrice(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.GasFeeCap.Cmp(big.NewInt(70e9)) != 0 {
+		t.Errorf("fee cap = %s, want 70 gwei", opts.GasFeeCap)
+	}
+}
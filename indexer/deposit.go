@@ -0,0 +1,386 @@
+package indexer
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ackermanx/ethclient/wallet"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// DefaultConfirmations is the number of blocks, the including block counted, after which a deposit is confirmed
+const DefaultConfirmations = 12
+
+var ErrReorgTooDeep = errors.New("chain reorganization below the last confirmed block")
+
+// DeriveAddresses derives count addresses of w from base, incrementing the last path component from offset.
+// The accounts are not pinned to the wallet.
+func DeriveAddresses(w *wallet.Wallet, base accounts.DerivationPath, offset, count uint32) ([]common.Address, error) {
+	addresses := make([]common.Address, 0, count)
+	for i := uint32(0); i < count; i++ {
+		path := make(accounts.DerivationPath, len(base))
+		copy(path, base)
+		path[len(path)-1] += offset + i
+		account, err := w.Derive(path, false)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "derive %s", path)
+		}
+		addresses = append(addresses, account.Address)
+	}
+	return addresses, nil
+}
+
+// Deposit is a transfer to a watched address
+type Deposit struct {
+	*Transfer
+	// Confirmations is the number of blocks from the deposit block to head when the event is emitted
+	Confirmations uint64
+}
+
+// DepositHandler receives the deposit events of a DepositWatcher. The events are delivered at least once,
+// a failed handler stops the poll and the event is emitted again by the next poll, so handlers have to be
+// idempotent on TxHash, Native(), LogIndex and TraceAddress. Native deposits have no log, their LogIndex is
+// zero like the first log of a block, Native() tells them apart.
+type DepositHandler interface {
+	// DepositSeen is called when a deposit is included in a block, before it is confirmed
+	DepositSeen(ctx context.Context, deposit *Deposit) error
+	// DepositConfirmed is called when the block of a deposit reaches the confirmations threshold
+	DepositConfirmed(ctx context.Context, deposit *Deposit) error
+	// DepositReverted is called when the block of a seen deposit is removed by a reorg,
+	// the deposit can be seen again in the new block
+	DepositReverted(ctx context.Context, deposit *Deposit) error
+}
+
+// Checkpoint is the last confirmed block processed by a DepositWatcher
+type Checkpoint struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// CheckpointStore persists the checkpoint of a DepositWatcher
+type CheckpointStore interface {
+	// LoadCheckpoint returns nil when no checkpoint was saved
+	LoadCheckpoint(ctx context.Context) (*Checkpoint, error)
+	SaveCheckpoint(ctx context.Context, checkpoint Checkpoint) error
+}
+
+// MemoryCheckpointStore keeps the checkpoint in memory, the watcher starts again from its
+// StartBlock after a restart
+type MemoryCheckpointStore struct {
+	mu         sync.Mutex
+	checkpoint *Checkpoint
+}
+
+func (s *MemoryCheckpointStore) LoadCheckpoint(ctx context.Context) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.checkpoint == nil {
+		return nil, nil
+	}
+	checkpoint := *s.checkpoint
+	return &checkpoint, nil
+}
+
+func (s *MemoryCheckpointStore) SaveCheckpoint(ctx context.Context, checkpoint Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoint = &checkpoint
+	return nil
+}
+
+// watchedBlock is a scanned block which is not confirmed yet
+type watchedBlock struct {
+	number   uint64
+	hash     common.Hash
+	deposits []*Deposit
+}
+
+// DepositWatcher scans new blocks for native and erc20 transfers to the watched addresses. The unconfirmed
+// blocks are kept in memory to roll back their deposits on reorgs, the checkpoint saved in the store is the
+// last confirmed block, after a restart the unconfirmed blocks are scanned and seen again.
+//
+// By default the native deposits are the value of the transactions sent directly to the addresses, the
+// native currency sent by contracts, like contract wallets or batch payouts, is missed. Set
+// InternalTransfers to read the native transfers from trace_block instead.
+type DepositWatcher struct {
+	client  *ethclient.Client
+	handler DepositHandler
+	store   CheckpointStore
+	// metadata fetch and cache the token metadata
+	metadata *TransferIndexer
+
+	// Confirmations is the number of blocks, the including block counted, after which a deposit is confirmed
+	Confirmations uint64
+	// StartBlock is the first block scanned when the store has no checkpoint, nil starts at head
+	StartBlock *big.Int
+	// Tokens restricts the erc20 deposits to these contracts, empty watches every token
+	Tokens []common.Address
+	// InternalTransfers reads the native deposits from trace_block, including the transfers of contract
+	// calls. The node has to serve the trace_* api.
+	InternalTransfers bool
+
+	mu        sync.RWMutex
+	addresses map[common.Address]bool
+
+	started   bool
+	next      uint64
+	confirmed *Checkpoint
+	window    []*watchedBlock
+}
+
+// NewDepositWatcher creates a watcher of addresses, nil store keeps the checkpoint in memory
+func NewDepositWatcher(client *ethclient.Client, addresses []common.Address, handler DepositHandler, store CheckpointStore) *DepositWatcher {
+	if store == nil {
+		store = &MemoryCheckpointStore{}
+	}
+	dw := &DepositWatcher{
+		client:        client,
+		handler:       handler,
+		store:         store,
+		metadata:      NewTransferIndexer(client, nil),
+		Confirmations: DefaultConfirmations,
+		addresses:     make(map[common.Address]bool, len(addresses)),
+	}
+	dw.AddAddresses(addresses...)
+	return dw
+}
+
+// AddAddresses adds watched addresses, they are watched from the next scanned block
+func (dw *DepositWatcher) AddAddresses(addresses ...common.Address) {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	for _, address := range addresses {
+		dw.addresses[address] = true
+	}
+}
+
+// Watched reports whether address is watched
+func (dw *DepositWatcher) Watched(address common.Address) bool {
+	dw.mu.RLock()
+	defer dw.mu.RUnlock()
+	return dw.addresses[address]
+}
+
+// Run polls every interval until ctx is done or a poll fails
+func (dw *DepositWatcher) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := dw.Poll(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll scans the blocks up to head, rolls back the blocks removed by reorgs and confirms the blocks
+// reaching the confirmations threshold. ErrReorgTooDeep is returned when a reorg removes a confirmed block.
+// Poll must not be called concurrently.
+func (dw *DepositWatcher) Poll(ctx context.Context) error {
+	head, err := dw.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return errors.WithMessage(err, "latest header")
+	}
+	if !dw.started {
+		if err = dw.start(ctx, head); err != nil {
+			return err
+		}
+	}
+
+	headNumber := head.Number.Uint64()
+	for dw.next <= headNumber {
+		block, err := dw.client.BlockByNumber(ctx, new(big.Int).SetUint64(dw.next))
+		if err != nil {
+			return errors.WithMessagef(err, "block %d", dw.next)
+		}
+		if parent := dw.parentHash(); parent != (common.Hash{}) && block.ParentHash() != parent {
+			if err = dw.rollback(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		deposits, err := dw.scan(ctx, block)
+		if err != nil {
+			return err
+		}
+		for _, deposit := range deposits {
+			deposit.Confirmations = headNumber - block.NumberU64() + 1
+			if err = dw.handler.DepositSeen(ctx, deposit); err != nil {
+				return errors.WithMessagef(err, "deposit %s seen", deposit.TxHash)
+			}
+		}
+		dw.window = append(dw.window, &watchedBlock{number: block.NumberU64(), hash: block.Hash(), deposits: deposits})
+		dw.next++
+
+		if err = dw.confirm(ctx, headNumber); err != nil {
+			return err
+		}
+	}
+	return dw.confirm(ctx, headNumber)
+}
+
+func (dw *DepositWatcher) start(ctx context.Context, head *types.Header) error {
+	checkpoint, err := dw.store.LoadCheckpoint(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "load checkpoint")
+	}
+	switch {
+	case checkpoint != nil:
+		dw.confirmed = checkpoint
+		dw.next = checkpoint.Number + 1
+	case dw.StartBlock != nil:
+		dw.next = dw.StartBlock.Uint64()
+	default:
+		dw.next = head.Number.Uint64()
+	}
+	dw.started = true
+	return nil
+}
+
+// parentHash returns the hash of the last scanned block, zero before the first block
+func (dw *DepositWatcher) parentHash() common.Hash {
+	if len(dw.window) > 0 {
+		return dw.window[len(dw.window)-1].hash
+	}
+	if dw.confirmed != nil {
+		return dw.confirmed.Hash
+	}
+	return common.Hash{}
+}
+
+// rollback removes the last scanned block after a reorg
+func (dw *DepositWatcher) rollback(ctx context.Context) error {
+	if len(dw.window) == 0 {
+		return errors.Wrapf(ErrReorgTooDeep, "block %d does not extend confirmed block %d %s", dw.next, dw.confirmed.Number, dw.confirmed.Hash)
+	}
+	last := dw.window[len(dw.window)-1]
+	for i := len(last.deposits) - 1; i >= 0; i-- {
+		deposit := last.deposits[i]
+		deposit.Confirmations = 0
+		if err := dw.handler.DepositReverted(ctx, deposit); err != nil {
+			// the block stays in the window, the next poll detects the reorg again
+			return errors.WithMessagef(err, "deposit %s reverted", deposit.TxHash)
+		}
+	}
+	dw.window = dw.window[:len(dw.window)-1]
+	dw.next = last.number
+	return nil
+}
+
+// confirm emits the deposits of the blocks reaching the confirmations threshold and saves the checkpoint
+func (dw *DepositWatcher) confirm(ctx context.Context, headNumber uint64) error {
+	for len(dw.window) > 0 {
+		block := dw.window[0]
+		confirmations := headNumber - block.number + 1
+		if confirmations < dw.Confirmations {
+			return nil
+		}
+		for _, deposit := range block.deposits {
+			deposit.Confirmations = confirmations
+			if err := dw.handler.DepositConfirmed(ctx, deposit); err != nil {
+				return errors.WithMessagef(err, "deposit %s confirmed", deposit.TxHash)
+			}
+		}
+		checkpoint := Checkpoint{Number: block.number, Hash: block.hash}
+		if err := dw.store.SaveCheckpoint(ctx, checkpoint); err != nil {
+			return errors.WithMessage(err, "save checkpoint")
+		}
+		dw.confirmed = &checkpoint
+		dw.window = dw.window[1:]
+	}
+	return nil
+}
+
+// scan returns the deposits of block, native deposits first
+func (dw *DepositWatcher) scan(ctx context.Context, block *types.Block) ([]*Deposit, error) {
+	var (
+		transfers []*Transfer
+		err       error
+	)
+	if dw.InternalTransfers {
+		transfers, err = dw.tracedTransfers(ctx, block)
+	} else {
+		transfers, err = blockNativeTransfers(ctx, dw.client, block, func(from, to common.Address) bool {
+			return dw.Watched(to)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the watched addresses are too many for a topic filter, every transfer of the block is matched locally
+	hash := block.Hash()
+	logs, err := dw.client.FilterLogs(ctx, ethereum.FilterQuery{
+		BlockHash: &hash,
+		Addresses: dw.Tokens,
+		Topics:    [][]common.Hash{{TransferTopic}},
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "filter logs of block %d", block.NumberU64())
+	}
+	blockTime := time.Unix(int64(block.Time()), 0).UTC()
+	for _, log := range logs {
+		if !isERC20Transfer(log) || !dw.Watched(common.BytesToAddress(log.Topics[2].Bytes())) {
+			continue
+		}
+		token, err := dw.metadata.Token(ctx, log.Address)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, logTransfer(log, token, blockTime))
+	}
+
+	deposits := make([]*Deposit, len(transfers))
+	for i, transfer := range transfers {
+		deposits[i] = &Deposit{Transfer: transfer}
+	}
+	return deposits, nil
+}
+
+// tracedTransfers returns the native transfers of block to the watched addresses from trace_block
+func (dw *DepositWatcher) tracedTransfers(ctx context.Context, block *types.Block) ([]*Transfer, error) {
+	values, err := dw.client.BlockValueTransfers(ctx, block.Number())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "trace block %d", block.NumberU64())
+	}
+	txIndexes := make(map[common.Hash]uint, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		txIndexes[tx.Hash()] = uint(i)
+	}
+	var transfers []*Transfer
+	blockTime := time.Unix(int64(block.Time()), 0).UTC()
+	for _, value := range values {
+		if !dw.Watched(value.To) {
+			continue
+		}
+		// trace_block is requested by number, the block may have been replaced since
+		txIndex, ok := txIndexes[value.TransactionHash]
+		if !ok {
+			return nil, errors.Errorf("traced transaction %s is not in block %d %s", value.TransactionHash, block.NumberU64(), block.Hash())
+		}
+		transfers = append(transfers, &Transfer{
+			From:         value.From,
+			To:           value.To,
+			Value:        value.Value,
+			BlockNumber:  block.NumberU64(),
+			BlockHash:    block.Hash(),
+			BlockTime:    blockTime,
+			TxHash:       value.TransactionHash,
+			TxIndex:      txIndex,
+			TraceAddress: value.TraceAddress,
+		})
+	}
+	return transfers, nil
+}
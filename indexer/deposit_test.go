@@ -0,0 +1,211 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ackermanx/ethclient/wallet"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingHandler struct {
+	events []string
+}
+
+func (h *recordingHandler) record(kind string, deposit *Deposit) error {
+	asset := "native"
+	if deposit.Token != nil {
+		asset = deposit.Token.Symbol
+	}
+	h.events = append(h.events, fmt.Sprintf("%s %d %s %s", kind, deposit.BlockNumber, asset, deposit.Value))
+	return nil
+}
+
+func (h *recordingHandler) DepositSeen(ctx context.Context, deposit *Deposit) error {
+	return h.record("seen", deposit)
+}
+
+func (h *recordingHandler) DepositConfirmed(ctx context.Context, deposit *Deposit) error {
+	return h.record("confirmed", deposit)
+}
+
+func (h *recordingHandler) DepositReverted(ctx context.Context, deposit *Deposit) error {
+	return h.record("reverted", deposit)
+}
+
+// buildChain replaces the blocks from number from to head, salt forks the chain
+func (n *fakeNode) buildChain(from, head, salt uint64, txs map[uint64][]*types.Transaction) {
+	parent := common.Hash{}
+	if block, ok := n.blocks[from-1]; ok {
+		parent = block.Hash()
+	}
+	for number := from; number <= head; number++ {
+		header := &types.Header{
+			ParentHash: parent,
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: new(big.Int),
+			Time:       1000 + number*12 + salt,
+		}
+		n.blocks[number] = types.NewBlock(header, txs[number], nil, nil, trie.NewStackTrie(nil))
+		parent = n.blocks[number].Hash()
+	}
+	for number := range n.blocks {
+		if number > head {
+			delete(n.blocks, number)
+		}
+	}
+	n.head = head
+}
+
+func (n *fakeNode) addLog(log types.Log) {
+	log.BlockHash = n.blocks[log.BlockNumber].Hash()
+	n.logs = append(n.logs, log)
+}
+
+func TestDepositWatcher(t *testing.T) {
+	seed := common.FromHex("0x2e5ae5e6e8d6e1d2c1f3f0a2b9e7e1a7b4c5d6e7f8091a2b3c4d5e6f708192a3")
+	w, err := wallet.NewFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addresses, err := DeriveAddresses(w, wallet.DefaultBaseDerivationPath, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := w.Derive(wallet.MustParseDerivationPath("m/44'/60'/0'/0/2"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, account.Address, addresses[2])
+	user := addresses[1]
+
+	key, _ := crypto.GenerateKey()
+	tx := types.MustSignNewTx(key, types.NewLondonSigner(big.NewInt(1)), &types.DynamicFeeTx{
+		ChainID: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 21000, To: &user, Value: big.NewInt(1e18),
+	})
+	node := &fakeNode{
+		maxRange: 1,
+		blocks:   map[uint64]*types.Block{},
+		senders:  map[common.Hash]common.Address{tx.Hash(): bob},
+		receipts: map[common.Hash]*types.Receipt{tx.Hash(): {Status: types.ReceiptStatusSuccessful, TxHash: tx.Hash(), Logs: []*types.Log{}}},
+	}
+	node.buildChain(1, 5, 0, map[uint64][]*types.Transaction{2: {tx}})
+	node.addLog(transferLog(usdc, carol, user, 2000000, 3, 0))
+	node.addLog(transferLog(usdc, carol, alice, 5, 3, 1))
+	node.addLog(transferLog(usdc, carol, user, 3000000, 5, 0))
+	node.head = 3
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err = server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	client := ethclient.NewClient(rpc.DialInProc(server))
+	defer client.Close()
+	handler := &recordingHandler{}
+	store := &MemoryCheckpointStore{}
+	dw := NewDepositWatcher(client, addresses, handler, store)
+	dw.Confirmations = 3
+	dw.StartBlock = big.NewInt(1)
+
+	assert.NoError(t, dw.Poll(context.Background()))
+	assert.Equal(t, []string{"seen 2 native 1000000000000000000", "seen 3 USDC 2000000"}, handler.events)
+
+	handler.events = nil
+	node.head = 5
+	assert.NoError(t, dw.Poll(context.Background()))
+	assert.Equal(t, []string{"confirmed 2 native 1000000000000000000", "confirmed 3 USDC 2000000", "seen 5 USDC 3000000"}, handler.events)
+	checkpoint, _ := store.LoadCheckpoint(context.Background())
+	assert.Equal(t, &Checkpoint{Number: 3, Hash: node.blocks[3].Hash()}, checkpoint)
+
+	// block 5 is replaced by a block without the deposit
+	handler.events = nil
+	node.buildChain(5, 6, 1, nil)
+	assert.NoError(t, dw.Poll(context.Background()))
+	assert.Equal(t, []string{"reverted 5 USDC 3000000"}, handler.events)
+	checkpoint, _ = store.LoadCheckpoint(context.Background())
+	assert.Equal(t, &Checkpoint{Number: 4, Hash: node.blocks[4].Hash()}, checkpoint)
+
+	// a restarted watcher continues after the checkpoint
+	restarted := NewDepositWatcher(client, addresses, &recordingHandler{}, store)
+	restarted.Confirmations = 3
+	assert.NoError(t, restarted.Poll(context.Background()))
+	assert.Equal(t, uint64(7), restarted.next)
+
+	node.buildChain(3, 7, 2, nil)
+	err = dw.Poll(context.Background())
+	assert.True(t, errors.Is(err, ErrReorgTooDeep), "%v", err)
+}
+
+// traceNode serves the trace_block of a contract wallet paying a watched address
+type traceNode struct {
+	traces map[uint64][]ethclient.Trace
+}
+
+func (n *traceNode) Block(number string) ([]ethclient.Trace, error) {
+	num, err := hexutil.DecodeUint64(number)
+	if err != nil {
+		return nil, err
+	}
+	return n.traces[num], nil
+}
+
+func TestDepositWatcherInternalTransfers(t *testing.T) {
+	user := common.Address{0xd0}
+	wallet := common.Address{0xe0}
+	key, _ := crypto.GenerateKey()
+	tx := types.MustSignNewTx(key, types.NewLondonSigner(big.NewInt(1)), &types.DynamicFeeTx{
+		ChainID: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 100000, To: &wallet,
+	})
+	node := &fakeNode{maxRange: 1, blocks: map[uint64]*types.Block{}, senders: map[common.Hash]common.Address{tx.Hash(): bob}}
+	node.buildChain(1, 2, 0, map[uint64][]*types.Transaction{2: {tx}})
+
+	txHash := tx.Hash()
+	call := func(from, to common.Address, value int64, traceAddress []int, failed bool) ethclient.Trace {
+		trace := ethclient.Trace{
+			Action:          ethclient.TraceAction{CallType: "call", From: &from, To: &to, Value: (*hexutil.Big)(big.NewInt(value))},
+			BlockNumber:     2,
+			TraceAddress:    traceAddress,
+			TransactionHash: &txHash,
+			Type:            "call",
+		}
+		if failed {
+			trace.Error = "Reverted"
+		}
+		return trace
+	}
+	traces := &traceNode{traces: map[uint64][]ethclient.Trace{2: {
+		call(bob, wallet, 0, []int{}, false),
+		call(wallet, user, 5e17, []int{0}, false),
+		call(wallet, user, 1e17, []int{1}, true),
+	}}}
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("trace", traces); err != nil {
+		t.Fatal(err)
+	}
+	client := ethclient.NewClient(rpc.DialInProc(server))
+	defer client.Close()
+	handler := &recordingHandler{}
+	dw := NewDepositWatcher(client, []common.Address{user}, handler, nil)
+	dw.Confirmations = 1
+	dw.StartBlock = big.NewInt(1)
+	dw.InternalTransfers = true
+
+	assert.NoError(t, dw.Poll(context.Background()))
+	assert.Equal(t, []string{"seen 2 native 500000000000000000", "confirmed 2 native 500000000000000000"}, handler.events)
+}
@@ -0,0 +1,445 @@
+// Package indexer builds per address ledgers of erc20 and native transfers.
+package indexer
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// TokenMetadataAbi contains the optional erc20 metadata methods
+	TokenMetadataAbi = `[{"inputs":[],"name":"name","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"symbol","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"}]`
+
+	// DefaultChunkSize is the number of blocks of one FilterLogs request
+	DefaultChunkSize = 2000
+	// nativeDecimals is the decimals of the native currency
+	nativeDecimals = 18
+)
+
+var (
+	// TransferTopic is the topic of the erc20 Transfer(address,address,uint256) event
+	TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	metadataAbi = mustParseAbi(TokenMetadataAbi)
+)
+
+func mustParseAbi(abiStr string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(abiStr))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// Direction is the direction of a ledger entry from the point of view of its account
+type Direction int
+
+const (
+	Incoming Direction = iota
+	Outgoing
+)
+
+func (d Direction) String() string {
+	if d == Outgoing {
+		return "out"
+	}
+	return "in"
+}
+
+// Token is the metadata of an erc20 token, the fields a token does not implement are empty
+type Token struct {
+	Address  common.Address
+	Name     string
+	Symbol   string
+	Decimals uint8
+}
+
+// Transfer is an erc20 Transfer event, or a native currency transfer when Token is nil
+type Transfer struct {
+	Token       *Token
+	From        common.Address
+	To          common.Address
+	Value       *big.Int
+	BlockNumber uint64
+	BlockHash   common.Hash
+	BlockTime   time.Time
+	TxHash      common.Hash
+	TxIndex     uint
+	// LogIndex is the index of the Transfer log in the block, zero for native transfers
+	LogIndex uint
+	// TraceAddress is the position in the call tree of a native transfer read from traces, nil otherwise
+	TraceAddress []int
+}
+
+// Native reports whether the transfer moves the native currency
+func (t *Transfer) Native() bool {
+	return t.Token == nil
+}
+
+// LedgerEntry is one side of a transfer for an indexed account, a transfer between two indexed
+// accounts produces an entry for each of them
+type LedgerEntry struct {
+	*Transfer
+	Account      common.Address
+	Counterparty common.Address
+	Direction    Direction
+	// Amount is Value scaled by the token decimals, always positive
+	Amount decimal.Decimal
+}
+
+// TransferIndexer finds the transfers in and out of a set of addresses
+type TransferIndexer struct {
+	client    *ethclient.Client
+	addresses map[common.Address]bool
+	topics    []common.Hash
+
+	// Tokens restricts the erc20 transfers to these contracts, empty indexes every token
+	Tokens []common.Address
+	// ChunkSize is the number of blocks requested in one FilterLogs call, the chunk is split when the
+	// node refuses a too large result
+	ChunkSize uint64
+	// IncludeNative adds the native value of the transactions from or to the addresses. Every block of
+	// the range is fetched, and the internal transfers of contract calls are not included.
+	IncludeNative bool
+
+	mu         sync.Mutex
+	tokens     map[common.Address]*Token
+	blockTimes map[uint64]time.Time
+}
+
+// NewTransferIndexer creates an indexer of the transfers of addresses
+func NewTransferIndexer(client *ethclient.Client, addresses []common.Address) *TransferIndexer {
+	ti := &TransferIndexer{
+		client:     client,
+		addresses:  make(map[common.Address]bool, len(addresses)),
+		ChunkSize:  DefaultChunkSize,
+		tokens:     make(map[common.Address]*Token),
+		blockTimes: make(map[uint64]time.Time),
+	}
+	for _, address := range addresses {
+		if !ti.addresses[address] {
+			ti.addresses[address] = true
+			ti.topics = append(ti.topics, common.BytesToHash(address.Bytes()))
+		}
+	}
+	return ti
+}
+
+// Ledger returns the entries of the transfers between fromBlock and toBlock included, ordered by block,
+// transaction and log. Native transfers come before the logs of their transaction.
+func (ti *TransferIndexer) Ledger(ctx context.Context, fromBlock, toBlock uint64) ([]LedgerEntry, error) {
+	transfers, err := ti.Transfers(ctx, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	var entries []LedgerEntry
+	for _, transfer := range transfers {
+		decimals := int32(nativeDecimals)
+		if transfer.Token != nil {
+			decimals = int32(transfer.Token.Decimals)
+		}
+		amount := decimal.NewFromBigInt(transfer.Value, -decimals)
+		if ti.addresses[transfer.From] {
+			entries = append(entries, LedgerEntry{Transfer: transfer, Account: transfer.From, Counterparty: transfer.To, Direction: Outgoing, Amount: amount})
+		}
+		if ti.addresses[transfer.To] {
+			entries = append(entries, LedgerEntry{Transfer: transfer, Account: transfer.To, Counterparty: transfer.From, Direction: Incoming, Amount: amount})
+		}
+	}
+	return entries, nil
+}
+
+// Transfers returns the transfers from or to the addresses between fromBlock and toBlock included
+func (ti *TransferIndexer) Transfers(ctx context.Context, fromBlock, toBlock uint64) ([]*Transfer, error) {
+	if fromBlock > toBlock {
+		return nil, errors.Errorf("from block %d after to block %d", fromBlock, toBlock)
+	}
+	if len(ti.addresses) == 0 {
+		return nil, nil
+	}
+	chunkSize := ti.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	var transfers []*Transfer
+	type logKey struct {
+		txHash common.Hash
+		index  uint
+	}
+	seen := make(map[logKey]bool)
+	for start := fromBlock; start <= toBlock; start += chunkSize {
+		end := start + chunkSize - 1
+		if end > toBlock || end < start {
+			end = toBlock
+		}
+		// the addresses are either the sender in topic1 or the recipient in topic2
+		for _, topics := range [][][]common.Hash{
+			{{TransferTopic}, ti.topics},
+			{{TransferTopic}, nil, ti.topics},
+		} {
+			logs, err := ti.filterLogs(ctx, start, end, topics)
+			if err != nil {
+				return nil, err
+			}
+			for _, log := range logs {
+				key := logKey{log.TxHash, log.Index}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				transfer, err := ti.decodeTransfer(ctx, log)
+				if err != nil {
+					return nil, err
+				}
+				if transfer != nil {
+					transfers = append(transfers, transfer)
+				}
+			}
+		}
+		if end == toBlock {
+			break
+		}
+	}
+
+	if ti.IncludeNative {
+		native, err := ti.nativeTransfers(ctx, fromBlock, toBlock)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, native...)
+	}
+	sort.SliceStable(transfers, func(i, j int) bool {
+		a, b := transfers[i], transfers[j]
+		if a.BlockNumber != b.BlockNumber {
+			return a.BlockNumber < b.BlockNumber
+		}
+		if a.TxIndex != b.TxIndex {
+			return a.TxIndex < b.TxIndex
+		}
+		if a.Native() != b.Native() {
+			return a.Native()
+		}
+		return a.LogIndex < b.LogIndex
+	})
+	return transfers, nil
+}
+
+// filterLogs fetch the logs of the range and split it while the node refuses the result size
+func (ti *TransferIndexer) filterLogs(ctx context.Context, start, end uint64, topics [][]common.Hash) ([]types.Log, error) {
+	logs, err := ti.client.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(start),
+		ToBlock:   new(big.Int).SetUint64(end),
+		Addresses: ti.Tokens,
+		Topics:    topics,
+	})
+	if err == nil {
+		return logs, nil
+	}
+	if start == end || !isTooManyResults(err) {
+		return nil, errors.WithMessagef(err, "filter logs of blocks %d-%d", start, end)
+	}
+	mid := start + (end-start)/2
+	logs, err = ti.filterLogs(ctx, start, mid, topics)
+	if err != nil {
+		return nil, err
+	}
+	more, err := ti.filterLogs(ctx, mid+1, end, topics)
+	if err != nil {
+		return nil, err
+	}
+	return append(logs, more...), nil
+}
+
+// isTooManyResults reports whether err is a node refusing a log query for its size
+func isTooManyResults(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"more than", "too many", "limit exceeded", "range is too large", "response size"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeTransfer returns nil for the logs which are not erc20 transfers
+func (ti *TransferIndexer) decodeTransfer(ctx context.Context, log types.Log) (*Transfer, error) {
+	if !isERC20Transfer(log) {
+		return nil, nil
+	}
+	token, err := ti.Token(ctx, log.Address)
+	if err != nil {
+		return nil, err
+	}
+	blockTime, err := ti.blockTime(ctx, log.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return logTransfer(log, token, blockTime), nil
+}
+
+// isERC20Transfer reports whether log is an erc20 Transfer event, erc721 transfers share
+// the topic and index the token id in a fourth topic
+func isERC20Transfer(log types.Log) bool {
+	return !log.Removed && len(log.Topics) == 3 && log.Topics[0] == TransferTopic && len(log.Data) == 32
+}
+
+func logTransfer(log types.Log, token *Token, blockTime time.Time) *Transfer {
+	return &Transfer{
+		Token:       token,
+		From:        common.BytesToAddress(log.Topics[1].Bytes()),
+		To:          common.BytesToAddress(log.Topics[2].Bytes()),
+		Value:       new(big.Int).SetBytes(log.Data),
+		BlockNumber: log.BlockNumber,
+		BlockHash:   log.BlockHash,
+		BlockTime:   blockTime,
+		TxHash:      log.TxHash,
+		TxIndex:     log.TxIndex,
+		LogIndex:    log.Index,
+	}
+}
+
+// Token returns the metadata of the token, it is cached after the first call. The metadata methods are
+// optional in erc20, a method which reverts or returns another type leaves its field empty.
+func (ti *TransferIndexer) Token(ctx context.Context, address common.Address) (*Token, error) {
+	ti.mu.Lock()
+	token, ok := ti.tokens[address]
+	ti.mu.Unlock()
+	if ok {
+		return token, nil
+	}
+
+	token = &Token{Address: address}
+	for _, method := range []string{"name", "symbol", "decimals"} {
+		input, err := metadataAbi.Pack(method)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "pack %s", method)
+		}
+		output, err := ti.client.CallContract(ctx, ethereum.CallMsg{To: &address, Data: input}, nil)
+		if err != nil {
+			// only a revert means the method is missing, the token is not cached after other errors
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			} else if !ethclient.IsExecutionError(err) {
+				return nil, errors.WithMessagef(err, "call %s of %s", method, address)
+			}
+			continue
+		}
+		res, err := metadataAbi.Unpack(method, output)
+		if err != nil || len(res) == 0 {
+			continue
+		}
+		switch value := res[0].(type) {
+		case string:
+			if method == "name" {
+				token.Name = value
+			} else {
+				token.Symbol = value
+			}
+		case uint8:
+			token.Decimals = value
+		}
+	}
+
+	ti.mu.Lock()
+	ti.tokens[address] = token
+	ti.mu.Unlock()
+	return token, nil
+}
+
+func (ti *TransferIndexer) blockTime(ctx context.Context, number uint64) (time.Time, error) {
+	ti.mu.Lock()
+	blockTime, ok := ti.blockTimes[number]
+	ti.mu.Unlock()
+	if ok {
+		return blockTime, nil
+	}
+	header, err := ti.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return time.Time{}, errors.WithMessagef(err, "header %d", number)
+	}
+	blockTime = time.Unix(int64(header.Time), 0).UTC()
+	ti.mu.Lock()
+	ti.blockTimes[number] = blockTime
+	ti.mu.Unlock()
+	return blockTime, nil
+}
+
+// nativeTransfers returns the successful transactions with value from or to the addresses
+func (ti *TransferIndexer) nativeTransfers(ctx context.Context, fromBlock, toBlock uint64) ([]*Transfer, error) {
+	var transfers []*Transfer
+	for number := fromBlock; number <= toBlock; number++ {
+		block, err := ti.client.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "block %d", number)
+		}
+		blockTransfers, err := blockNativeTransfers(ctx, ti.client, block, func(from, to common.Address) bool {
+			return ti.addresses[from] || ti.addresses[to]
+		})
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, blockTransfers...)
+		if number == toBlock {
+			break
+		}
+	}
+	return transfers, nil
+}
+
+// blockNativeTransfers returns the successful transactions of block with value for which match is true
+func blockNativeTransfers(ctx context.Context, client *ethclient.Client, block *types.Block, match func(from, to common.Address) bool) ([]*Transfer, error) {
+	var transfers []*Transfer
+	blockTime := time.Unix(int64(block.Time()), 0).UTC()
+	for i, tx := range block.Transactions() {
+		if tx.Value().Sign() == 0 {
+			continue
+		}
+		from, err := client.TransactionSender(ctx, tx, block.Hash(), uint(i))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "sender of %s", tx.Hash())
+		}
+		var to common.Address
+		if tx.To() != nil {
+			to = *tx.To()
+		}
+		if !match(from, to) {
+			continue
+		}
+		receipt, err := client.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			return nil, errors.WithMessagef(err, "receipt of %s", tx.Hash())
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		// the value of a contract creation goes to the created contract
+		if tx.To() == nil {
+			to = receipt.ContractAddress
+		}
+		transfers = append(transfers, &Transfer{
+			From:        from,
+			To:          to,
+			Value:       tx.Value(),
+			BlockNumber: block.NumberU64(),
+			BlockHash:   block.Hash(),
+			BlockTime:   blockTime,
+			TxHash:      tx.Hash(),
+			TxIndex:     uint(i),
+		})
+	}
+	return transfers, nil
+}
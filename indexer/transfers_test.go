@@ -0,0 +1,266 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	alice = common.Address{0xa1}
+	bob   = common.Address{0xb0}
+	carol = common.Address{0xc0}
+	usdc  = common.Address{0x05}
+	nft   = common.Address{0x06}
+)
+
+type fakeNode struct {
+	logs     []types.Log
+	blocks   map[uint64]*types.Block
+	senders  map[common.Hash]common.Address
+	receipts map[common.Hash]*types.Receipt
+	maxRange uint64
+	head     uint64
+	callErr  error
+}
+
+func transferLog(token common.Address, from, to common.Address, value int64, block uint64, index uint) types.Log {
+	return types.Log{
+		Address:     token,
+		Topics:      []common.Hash{TransferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:        common.LeftPadBytes(big.NewInt(value).Bytes(), 32),
+		BlockNumber: block,
+		TxHash:      common.Hash{byte(block), byte(index)},
+		Index:       index,
+	}
+}
+
+func (n *fakeNode) header(number uint64) *types.Header {
+	if block, ok := n.blocks[number]; ok {
+		return block.Header()
+	}
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		Difficulty: new(big.Int),
+		Time:       1000 + number*12,
+		UncleHash:  types.EmptyUncleHash,
+		TxHash:     types.EmptyRootHash,
+	}
+}
+
+func (n *fakeNode) GetLogs(filter map[string]interface{}) ([]types.Log, error) {
+	var from, to uint64
+	var blockHash common.Hash
+	if hash, ok := filter["blockHash"]; ok {
+		blockHash = common.HexToHash(hash.(string))
+		for number, block := range n.blocks {
+			if block.Hash() == blockHash {
+				from, to = number, number
+			}
+		}
+	} else {
+		from, _ = hexutil.DecodeUint64(filter["fromBlock"].(string))
+		to, _ = hexutil.DecodeUint64(filter["toBlock"].(string))
+	}
+	if to-from+1 > n.maxRange {
+		return nil, errors.New("query returned more than 10000 results")
+	}
+	topics := filter["topics"].([]interface{})
+	position := len(topics) - 1
+	var logs []types.Log
+	for _, log := range n.logs {
+		if log.BlockNumber < from || log.BlockNumber > to || (blockHash != common.Hash{} && log.BlockHash != blockHash) {
+			continue
+		}
+		for _, topic := range topics[position].([]interface{}) {
+			if common.HexToHash(topic.(string)) == log.Topics[position] {
+				logs = append(logs, log)
+				break
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (n *fakeNode) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	if n.callErr != nil {
+		return nil, n.callErr
+	}
+	if common.HexToAddress(args["to"].(string)) != usdc {
+		return nil, errors.New("execution reverted")
+	}
+	selector := common.FromHex(args["data"].(string))
+	for name, method := range metadataAbi.Methods {
+		if string(method.ID) == string(selector) {
+			switch name {
+			case "name":
+				return method.Outputs.Pack("USD Coin")
+			case "symbol":
+				return method.Outputs.Pack("USDC")
+			default:
+				return method.Outputs.Pack(uint8(6))
+			}
+		}
+	}
+	return nil, errors.New("execution reverted")
+}
+
+func (n *fakeNode) GetBlockByNumber(number string, full bool) (json.RawMessage, error) {
+	num := n.head
+	if number != "latest" {
+		var err error
+		if num, err = hexutil.DecodeUint64(number); err != nil {
+			return nil, err
+		}
+	}
+	header := n.header(num)
+	var fields map[string]interface{}
+	data, _ := json.Marshal(header)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	fields["hash"] = header.Hash()
+	fields["uncles"] = []common.Hash{}
+	txs := []map[string]interface{}{}
+	if block, ok := n.blocks[num]; ok {
+		for _, tx := range block.Transactions() {
+			var txFields map[string]interface{}
+			data, _ := json.Marshal(tx)
+			if err := json.Unmarshal(data, &txFields); err != nil {
+				return nil, err
+			}
+			txFields["from"] = n.senders[tx.Hash()]
+			txFields["blockHash"] = header.Hash()
+			txs = append(txs, txFields)
+		}
+	}
+	fields["transactions"] = txs
+	return json.Marshal(fields)
+}
+
+func (n *fakeNode) GetTransactionReceipt(hash common.Hash) *types.Receipt {
+	return n.receipts[hash]
+}
+
+func newTestIndexer(t *testing.T, node *fakeNode, addresses ...common.Address) *TransferIndexer {
+	server := rpc.NewServer()
+	t.Cleanup(server.Stop)
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	client := ethclient.NewClient(rpc.DialInProc(server))
+	t.Cleanup(client.Close)
+	return NewTransferIndexer(client, addresses)
+}
+
+func TestLedger(t *testing.T) {
+	node := &fakeNode{maxRange: 50}
+	node.logs = []types.Log{
+		transferLog(usdc, alice, bob, 1500000, 10, 0),
+		transferLog(usdc, carol, alice, 250000, 75, 3),
+		transferLog(usdc, bob, carol, 1, 80, 0),
+		// erc721 transfers share the topic and index the token id
+		{Address: nft, Topics: []common.Hash{TransferTopic, common.BytesToHash(carol.Bytes()), common.BytesToHash(alice.Bytes()), {1}}, BlockNumber: 90},
+	}
+	ti := newTestIndexer(t, node, alice, bob)
+	ti.ChunkSize = 100
+
+	entries, err := ti.Ledger(context.Background(), 0, 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, entries, 4) {
+		return
+	}
+	expected := []struct {
+		block        uint64
+		account      common.Address
+		counterparty common.Address
+		direction    Direction
+		amount       string
+	}{
+		{10, alice, bob, Outgoing, "1.5"},
+		{10, bob, alice, Incoming, "1.5"},
+		{75, alice, carol, Incoming, "0.25"},
+		{80, bob, carol, Outgoing, "0.000001"},
+	}
+	for i, e := range expected {
+		entry := entries[i]
+		assert.Equal(t, e.block, entry.BlockNumber)
+		assert.Equal(t, e.account, entry.Account)
+		assert.Equal(t, e.counterparty, entry.Counterparty)
+		assert.Equal(t, e.direction, entry.Direction)
+		assert.Equal(t, e.amount, entry.Amount.String())
+		assert.Equal(t, "USDC", entry.Token.Symbol)
+		assert.Equal(t, int64(1000+e.block*12), entry.BlockTime.Unix())
+	}
+}
+
+func TestNativeTransfers(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewLondonSigner(big.NewInt(1))
+	newTx := func(nonce uint64, to common.Address, value int64) *types.Transaction {
+		return types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID: big.NewInt(1), Nonce: nonce, GasFeeCap: big.NewInt(1), Gas: 21000, To: &to, Value: big.NewInt(value),
+		})
+	}
+	txs := []*types.Transaction{newTx(0, alice, 2e18), newTx(1, carol, 5), newTx(2, alice, 7)}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(5), Difficulty: new(big.Int), Time: 1060}, txs, nil, nil, trie.NewStackTrie(nil))
+	node := &fakeNode{
+		maxRange: 100,
+		blocks:   map[uint64]*types.Block{5: block},
+		senders:  map[common.Hash]common.Address{},
+		receipts: map[common.Hash]*types.Receipt{},
+	}
+	for i, tx := range txs {
+		node.senders[tx.Hash()] = bob
+		node.receipts[tx.Hash()] = &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: tx.Hash(), Logs: []*types.Log{}}
+		if i == 2 {
+			node.receipts[tx.Hash()].Status = types.ReceiptStatusFailed
+		}
+	}
+	node.logs = []types.Log{transferLog(usdc, carol, alice, 1, 5, 0)}
+	ti := newTestIndexer(t, node, alice)
+	ti.IncludeNative = true
+
+	transfers, err := ti.Transfers(context.Background(), 4, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, transfers, 2) {
+		return
+	}
+	assert.True(t, transfers[0].Native())
+	assert.Equal(t, bob, transfers[0].From)
+	assert.Equal(t, big.NewInt(2e18), transfers[0].Value)
+	assert.Equal(t, int64(1060), transfers[0].BlockTime.Unix())
+	assert.False(t, transfers[1].Native())
+}
+
+func TestTokenCallError(t *testing.T) {
+	node := &fakeNode{callErr: errors.New("request timed out")}
+	ti := newTestIndexer(t, node)
+
+	_, err := ti.Token(context.Background(), usdc)
+	assert.Error(t, err)
+
+	node.callErr = nil
+	token, err := ti.Token(context.Background(), usdc)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(6), token.Decimals)
+
+	token, err = ti.Token(context.Background(), nft)
+	assert.NoError(t, err)
+	assert.Equal(t, &Token{Address: nft}, token)
+}
@@ -0,0 +1,100 @@
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// OverrideAccount specifies the state of an account to be overridden during eth_call.
+// Zero fields are not overridden. State replaces the whole storage of the account,
+// StateDiff only the given slots, they are mutually exclusive.
+type OverrideAccount struct {
+	Nonce     uint64
+	Code      []byte
+	Balance   *big.Int
+	State     map[common.Hash]common.Hash
+	StateDiff map[common.Hash]common.Hash
+}
+
+func (a OverrideAccount) MarshalJSON() ([]byte, error) {
+	type override struct {
+		Nonce     *hexutil.Uint64             `json:"nonce,omitempty"`
+		Code      *hexutil.Bytes              `json:"code,omitempty"`
+		Balance   *hexutil.Big                `json:"balance,omitempty"`
+		State     map[common.Hash]common.Hash `json:"state,omitempty"`
+		StateDiff map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+	}
+
+	output := override{
+		Balance:   (*hexutil.Big)(a.Balance),
+		State:     a.State,
+		StateDiff: a.StateDiff,
+	}
+	if a.Nonce != 0 {
+		output.Nonce = (*hexutil.Uint64)(&a.Nonce)
+	}
+	if a.Code != nil {
+		output.Code = (*hexutil.Bytes)(&a.Code)
+	}
+	return json.Marshal(output)
+}
+
+// BlockOverrides specifies the block fields to be overridden during eth_call, zero fields are not overridden
+type BlockOverrides struct {
+	Number     *big.Int
+	Difficulty *big.Int
+	Time       uint64
+	GasLimit   uint64
+	Coinbase   *common.Address
+	Random     *common.Hash
+	BaseFee    *big.Int
+}
+
+func (o BlockOverrides) MarshalJSON() ([]byte, error) {
+	type override struct {
+		Number     *hexutil.Big    `json:"number,omitempty"`
+		Difficulty *hexutil.Big    `json:"difficulty,omitempty"`
+		Time       hexutil.Uint64  `json:"time,omitempty"`
+		GasLimit   hexutil.Uint64  `json:"gasLimit,omitempty"`
+		Coinbase   *common.Address `json:"feeRecipient,omitempty"`
+		Random     *common.Hash    `json:"prevRandao,omitempty"`
+		BaseFee    *hexutil.Big    `json:"baseFeePerGas,omitempty"`
+	}
+
+	return json.Marshal(override{
+		Number:     (*hexutil.Big)(o.Number),
+		Difficulty: (*hexutil.Big)(o.Difficulty),
+		Time:       hexutil.Uint64(o.Time),
+		GasLimit:   hexutil.Uint64(o.GasLimit),
+		Coinbase:   o.Coinbase,
+		Random:     o.Random,
+		BaseFee:    (*hexutil.Big)(o.BaseFee),
+	})
+}
+
+// CallOverrides are the state and block overrides of CallWithOverrides
+type CallOverrides struct {
+	State map[common.Address]OverrideAccount
+	Block *BlockOverrides
+}
+
+// CallContractWithOverrides executes a message call transaction like CallContract, with the account
+// state and block fields overridden. blockNumber -1 selects the pending block.
+// Block overrides are only supported by recent nodes.
+func (ec *Client) CallContractWithOverrides(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int, overrides map[common.Address]OverrideAccount, blockOverrides *BlockOverrides) ([]byte, error) {
+	var hex hexutil.Bytes
+	args := []interface{}{toCallArg(msg), toBlockNumArg(blockNumber), overrides}
+	if blockOverrides != nil {
+		args = append(args, blockOverrides)
+	}
+	err := ec.c.CallContext(ctx, &hex, "eth_call", args...)
+	if err != nil {
+		return nil, err
+	}
+	return hex, nil
+}
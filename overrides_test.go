@@ -0,0 +1,79 @@
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type callService struct {
+	block          string
+	overrides      map[string]map[string]interface{}
+	blockOverrides map[string]interface{}
+}
+
+func (s *callService) Call(args map[string]interface{}, block string, overrides map[string]map[string]interface{}, blockOverrides *map[string]interface{}) hexutil.Bytes {
+	s.block, s.overrides = block, overrides
+	if blockOverrides != nil {
+		s.blockOverrides = *blockOverrides
+	}
+	return common.LeftPadBytes([]byte{42}, 32)
+}
+
+func TestOverridesMarshal(t *testing.T) {
+	data, err := json.Marshal(OverrideAccount{Balance: big.NewInt(16), StateDiff: map[common.Hash]common.Hash{{1}: {2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"balance":"0x10","stateDiff":{"0x0100000000000000000000000000000000000000000000000000000000000000":"0x0200000000000000000000000000000000000000000000000000000000000000"}}`
+	if string(data) != expected {
+		t.Errorf("got %s, want %s", data, expected)
+	}
+
+	data, err = json.Marshal(BlockOverrides{Time: 100, BaseFee: big.NewInt(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"time":"0x64","baseFeePerGas":"0x0"}` {
+		t.Errorf("got %s", data)
+	}
+}
+
+func TestCallWithOverrides(t *testing.T) {
+	service := &callService{}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+
+	token := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	account := common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	var results = make([]interface{}, 0)
+	err := ec.CallWithOverrides(token, nil, &CallOverrides{
+		State: map[common.Address]OverrideAccount{token: {Code: []byte{0x60}}},
+		Block: &BlockOverrides{Number: big.NewInt(100)},
+	}, &results, "balanceOf", `[{"constant":true,"inputs":[{"name":"","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"type":"function"}]`, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].(*big.Int).Int64() != 42 {
+		t.Errorf("result %v, want 42", results[0])
+	}
+	if service.block != "latest" || service.overrides[hexutil.Encode(token.Bytes())]["code"] != "0x60" || service.blockOverrides["number"] != "0x64" {
+		t.Errorf("unexpected call block %s overrides %v block overrides %v", service.block, service.overrides, service.blockOverrides)
+	}
+
+	_, err = ec.CallContractWithOverrides(context.Background(), ethereum.CallMsg{To: &token}, big.NewInt(-1), nil, nil)
+	if err != nil || service.block != "pending" {
+		t.Errorf("pending call error %v block %s", err, service.block)
+	}
+}
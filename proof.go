@@ -0,0 +1,185 @@
+package ethclient
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidProof = errors.New("invalid merkle proof")
+
+	emptyCodeHash = crypto.Keccak256Hash(nil)
+)
+
+// AccountResult is the result of eth_getProof
+type AccountResult struct {
+	Address      common.Address
+	AccountProof []hexutil.Bytes
+	Balance      *big.Int
+	CodeHash     common.Hash
+	Nonce        uint64
+	StorageHash  common.Hash
+	StorageProof []StorageResult
+}
+
+// StorageResult is the proof of a storage slot of eth_getProof
+type StorageResult struct {
+	Key   common.Hash
+	Value *big.Int
+	Proof []hexutil.Bytes
+}
+
+type storageResultMarshaling struct {
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+type accountResultMarshaling struct {
+	Address      common.Address            `json:"address"`
+	AccountProof []hexutil.Bytes           `json:"accountProof"`
+	Balance      *hexutil.Big              `json:"balance"`
+	CodeHash     common.Hash               `json:"codeHash"`
+	Nonce        hexutil.Uint64            `json:"nonce"`
+	StorageHash  common.Hash               `json:"storageHash"`
+	StorageProof []storageResultMarshaling `json:"storageProof"`
+}
+
+// GetProof returns the account and storage values of account including the Merkle proofs at block number,
+// nil number is the latest block. The result has to be for account and storageKeys in order, errors match
+// ErrInvalidProof otherwise. The values can not be trusted before VerifyProof.
+func (ec *Client) GetProof(ctx context.Context, account common.Address, storageKeys []common.Hash, blockNumber *big.Int) (*AccountResult, error) {
+	if storageKeys == nil {
+		storageKeys = []common.Hash{}
+	}
+	var res accountResultMarshaling
+	if err := ec.c.CallContext(ctx, &res, "eth_getProof", account, storageKeys, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	if res.Balance == nil {
+		return nil, errors.Errorf("no proof of account %s", account)
+	}
+	// a valid proof of another account or other slots would pass VerifyProof
+	if res.Address != account {
+		return nil, errors.Wrapf(ErrInvalidProof, "got proof of account %s, want %s", res.Address, account)
+	}
+	if len(res.StorageProof) != len(storageKeys) {
+		return nil, errors.Wrapf(ErrInvalidProof, "got %d storage proofs of %s, want %d", len(res.StorageProof), account, len(storageKeys))
+	}
+
+	result := &AccountResult{
+		Address:      res.Address,
+		AccountProof: res.AccountProof,
+		Balance:      res.Balance.ToInt(),
+		CodeHash:     res.CodeHash,
+		Nonce:        uint64(res.Nonce),
+		StorageHash:  res.StorageHash,
+		StorageProof: make([]StorageResult, 0, len(res.StorageProof)),
+	}
+	for i, storage := range res.StorageProof {
+		if key := common.HexToHash(storage.Key); key != storageKeys[i] {
+			return nil, errors.Wrapf(ErrInvalidProof, "storage proof %d of %s is for key %s, want %s", i, account, key, storageKeys[i])
+		}
+		value := new(big.Int)
+		if storage.Value != nil {
+			value = storage.Value.ToInt()
+		}
+		result.StorageProof = append(result.StorageProof, StorageResult{
+			Key:   common.HexToHash(storage.Key),
+			Value: value,
+			Proof: storage.Proof,
+		})
+	}
+	return result, nil
+}
+
+// VerifyProof verify the account and storage values of result against the state root of a trusted header,
+// errors match ErrInvalidProof
+func VerifyProof(stateRoot common.Hash, result *AccountResult) error {
+	value, err := verifyMerkleProof(stateRoot, crypto.Keccak256(result.Address.Bytes()), result.AccountProof)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidProof, "account %s: %v", result.Address, err)
+	}
+	if value == nil {
+		// the account does not exist, nodes return either zero or empty code hash then
+		if result.Nonce != 0 || result.Balance.Sign() != 0 ||
+			(result.StorageHash != types.EmptyRootHash && result.StorageHash != (common.Hash{})) ||
+			(result.CodeHash != emptyCodeHash && result.CodeHash != (common.Hash{})) {
+			return errors.Wrapf(ErrInvalidProof, "account %s is absent from the state", result.Address)
+		}
+	} else {
+		var account types.StateAccount
+		if err = rlp.DecodeBytes(value, &account); err != nil {
+			return errors.Wrapf(ErrInvalidProof, "decode account %s: %v", result.Address, err)
+		}
+		if account.Nonce != result.Nonce || account.Balance.Cmp(result.Balance) != 0 ||
+			account.Root != result.StorageHash || !bytes.Equal(account.CodeHash, result.CodeHash.Bytes()) {
+			return errors.Wrapf(ErrInvalidProof, "account %s does not match the proof", result.Address)
+		}
+	}
+
+	emptyStorage := result.StorageHash == types.EmptyRootHash || result.StorageHash == (common.Hash{})
+	for _, storage := range result.StorageProof {
+		// the proof of an empty storage trie has no node
+		if emptyStorage {
+			if storage.Value.Sign() != 0 || len(storage.Proof) != 0 {
+				return errors.Wrapf(ErrInvalidProof, "storage %s of %s is not empty", storage.Key, result.Address)
+			}
+			continue
+		}
+		value, err = verifyMerkleProof(result.StorageHash, crypto.Keccak256(storage.Key.Bytes()), storage.Proof)
+		if err != nil {
+			return errors.Wrapf(ErrInvalidProof, "storage %s of %s: %v", storage.Key, result.Address, err)
+		}
+		proven := new(big.Int)
+		if value != nil {
+			var content []byte
+			if err = rlp.DecodeBytes(value, &content); err != nil {
+				return errors.Wrapf(ErrInvalidProof, "decode storage %s of %s: %v", storage.Key, result.Address, err)
+			}
+			proven.SetBytes(content)
+		}
+		if proven.Cmp(storage.Value) != 0 {
+			return errors.Wrapf(ErrInvalidProof, "storage %s of %s is %s, proof has %s", storage.Key, result.Address, storage.Value, proven)
+		}
+	}
+	return nil
+}
+
+func verifyMerkleProof(root common.Hash, key []byte, proof []hexutil.Bytes) ([]byte, error) {
+	proofDb := memorydb.New()
+	for _, node := range proof {
+		if err := proofDb.Put(crypto.Keccak256(node), node); err != nil {
+			return nil, err
+		}
+	}
+	return trie.VerifyProof(root, key, proofDb)
+}
+
+// GetVerifiedProof read the header of block number and the proof at the same block, then verify the proof
+// against the header state root. The header itself comes from the node, compare its hash with a trusted
+// source or use VerifyProof with a trusted state root when the node is not trusted.
+func (ec *Client) GetVerifiedProof(ctx context.Context, account common.Address, storageKeys []common.Hash, blockNumber *big.Int) (*AccountResult, *types.Header, error) {
+	header, err := ec.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "header by number")
+	}
+	result, err := ec.GetProof(ctx, account, storageKeys, header.Number)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "get proof")
+	}
+	if err = VerifyProof(header.Root, result); err != nil {
+		return nil, nil, err
+	}
+	return result, header, nil
+}
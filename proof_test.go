@@ -0,0 +1,152 @@
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+type proofList []hexutil.Bytes
+
+func (l *proofList) Put(key []byte, value []byte) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *proofList) Delete(key []byte) error {
+	panic("not supported")
+}
+
+func prove(t *testing.T, tr *trie.Trie, key []byte) []hexutil.Bytes {
+	var proof proofList
+	if err := tr.Prove(crypto.Keccak256(key), 0, &proof); err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+func TestVerifyProof(t *testing.T) {
+	storageTrie := trie.NewEmpty(trie.NewDatabase(memorydb.New()))
+	slots := map[common.Hash]*big.Int{{1}: big.NewInt(1000), {2}: big.NewInt(7)}
+	for slot, value := range slots {
+		encoded, _ := rlp.EncodeToBytes(value.Bytes())
+		storageTrie.Update(crypto.Keccak256(slot.Bytes()), encoded)
+	}
+
+	address := common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	codeHash := crypto.Keccak256Hash([]byte{0x60})
+	account := types.StateAccount{Nonce: 3, Balance: big.NewInt(1e18), Root: storageTrie.Hash(), CodeHash: codeHash.Bytes()}
+	accountTrie := trie.NewEmpty(trie.NewDatabase(memorydb.New()))
+	encoded, _ := rlp.EncodeToBytes(&account)
+	accountTrie.Update(crypto.Keccak256(address.Bytes()), encoded)
+	accountTrie.Update(crypto.Keccak256(common.HexToAddress("0x01").Bytes()), encoded)
+	stateRoot := accountTrie.Hash()
+
+	result := &AccountResult{
+		Address:      address,
+		AccountProof: prove(t, accountTrie, address.Bytes()),
+		Balance:      big.NewInt(1e18),
+		CodeHash:     codeHash,
+		Nonce:        3,
+		StorageHash:  storageTrie.Hash(),
+		StorageProof: []StorageResult{
+			{Key: common.Hash{1}, Value: big.NewInt(1000), Proof: prove(t, storageTrie, common.Hash{1}.Bytes())},
+			{Key: common.Hash{3}, Value: big.NewInt(0), Proof: prove(t, storageTrie, common.Hash{3}.Bytes())},
+		},
+	}
+	if err := VerifyProof(stateRoot, result); err != nil {
+		t.Fatal(err)
+	}
+
+	result.StorageProof[0].Value = big.NewInt(1001)
+	if err := VerifyProof(stateRoot, result); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("forged storage value accepted: %v", err)
+	}
+	result.StorageProof[0].Value = big.NewInt(1000)
+
+	result.Balance = big.NewInt(2e18)
+	if err := VerifyProof(stateRoot, result); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("forged balance accepted: %v", err)
+	}
+	result.Balance = big.NewInt(1e18)
+
+	if err := VerifyProof(common.Hash{1}, result); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("proof accepted against another root: %v", err)
+	}
+
+	absent := common.HexToAddress("0x02")
+	absentResult := &AccountResult{
+		Address:      absent,
+		AccountProof: prove(t, accountTrie, absent.Bytes()),
+		Balance:      new(big.Int),
+		CodeHash:     crypto.Keccak256Hash(nil),
+		StorageHash:  types.EmptyRootHash,
+		StorageProof: []StorageResult{{Key: common.Hash{1}, Value: new(big.Int)}},
+	}
+	if err := VerifyProof(stateRoot, absentResult); err != nil {
+		t.Errorf("absent account with empty storage rejected: %v", err)
+	}
+	absentResult.StorageProof[0].Value = big.NewInt(1)
+	if err := VerifyProof(stateRoot, absentResult); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("forged value of empty storage accepted: %v", err)
+	}
+}
+
+// proofService returns an empty storage proof of address for every key in keys
+type proofService struct {
+	address common.Address
+	keys    []common.Hash
+}
+
+func (s *proofService) GetProof(address common.Address, keys []common.Hash, block string) map[string]interface{} {
+	storage := make([]map[string]interface{}, len(s.keys))
+	for i, key := range s.keys {
+		storage[i] = map[string]interface{}{"key": key, "value": "0x0", "proof": []string{}}
+	}
+	return map[string]interface{}{
+		"address":      s.address,
+		"accountProof": []string{},
+		"balance":      "0x0",
+		"codeHash":     crypto.Keccak256Hash(nil),
+		"nonce":        "0x0",
+		"storageHash":  types.EmptyRootHash,
+		"storageProof": storage,
+	}
+}
+
+func TestGetProofMismatch(t *testing.T) {
+	account := common.HexToAddress("0x01")
+	keys := []common.Hash{{1}, {2}}
+	service := &proofService{address: account, keys: keys}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+
+	if _, err := ec.GetProof(context.Background(), account, keys, nil); err != nil {
+		t.Fatal(err)
+	}
+	for name, forged := range map[string]proofService{
+		"other account": {address: common.HexToAddress("0x02"), keys: keys},
+		"dropped key":   {address: account, keys: keys[:1]},
+		"other order":   {address: account, keys: []common.Hash{{2}, {1}}},
+	} {
+		*service = forged
+		if _, err := ec.GetProof(context.Background(), account, keys, nil); !errors.Is(err, ErrInvalidProof) {
+			t.Errorf("%s accepted: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package ethclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/pkg/errors"
+)
+
+// receiptBatchSize is the number of eth_getTransactionReceipt requests sent in one batch
+const receiptBatchSize = 100
+
+var ErrReceiptsMismatch = errors.New("receipts do not match the block receipt root")
+
+type rpcBlockTxHashes struct {
+	Hash         common.Hash   `json:"hash"`
+	Transactions []common.Hash `json:"transactions"`
+}
+
+// BlockReceipts returns the receipts of all transactions of the block, in transaction order.
+// eth_getBlockReceipts is used when the node supports it, otherwise the receipts are fetched with batched
+// eth_getTransactionReceipt. The receipts are checked against the ReceiptHash of the block header,
+// ErrReceiptsMismatch is returned when the node returned an incomplete or inconsistent set.
+func (ec *Client) BlockReceipts(ctx context.Context, blockRef rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	header, body, err := ec.blockTxHashes(ctx, blockRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var receipts []*types.Receipt
+	// the receipts are requested by hash, a number could move to another block after a reorg
+	err = ec.c.CallContext(ctx, &receipts, "eth_getBlockReceipts", body.Hash)
+	if isMethodNotFound(err) {
+		receipts, err = ec.batchReceipts(ctx, body.Transactions)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(receipts) != len(body.Transactions) {
+		return nil, errors.Wrapf(ErrReceiptsMismatch, "got %d receipts for %d transactions of block %s",
+			len(receipts), len(body.Transactions), body.Hash)
+	}
+	for i, receipt := range receipts {
+		if receipt == nil {
+			return nil, errors.Wrapf(ErrReceiptsMismatch, "missing receipt %d of block %s", i, body.Hash)
+		}
+		if receipt.TxHash != body.Transactions[i] {
+			return nil, errors.Wrapf(ErrReceiptsMismatch, "receipt %d is for transaction %s, want %s",
+				i, receipt.TxHash, body.Transactions[i])
+		}
+	}
+	if root := types.DeriveSha(consensusReceipts(receipts), trie.NewStackTrie(nil)); root != header.ReceiptHash {
+		return nil, errors.Wrapf(ErrReceiptsMismatch, "block %s receipt root is %s, derived %s",
+			body.Hash, header.ReceiptHash, root)
+	}
+	return receipts, nil
+}
+
+// consensusReceipts encodes receipts of any transaction type for DeriveSha, go-ethereum v1.10.25
+// types.Receipts writes nothing for types above DynamicFeeTxType like blob and set code transactions
+type consensusReceipts []*types.Receipt
+
+func (rs consensusReceipts) Len() int { return len(rs) }
+
+// EncodeIndex encodes the i'th receipt as the type byte followed by RLP(status, cumulative gas, bloom, logs),
+// legacy receipts have no type byte
+func (rs consensusReceipts) EncodeIndex(i int, w *bytes.Buffer) {
+	r := rs[i]
+	status := r.PostState
+	if len(status) == 0 {
+		status = []byte{}
+		if r.Status == types.ReceiptStatusSuccessful {
+			status = []byte{0x01}
+		}
+	}
+	if r.Type != types.LegacyTxType {
+		w.WriteByte(r.Type)
+	}
+	// encoding a status, a number, a bloom and logs does not fail
+	_ = rlp.Encode(w, []interface{}{status, r.CumulativeGasUsed, r.Bloom, r.Logs})
+}
+
+// blockTxHashes returns the header and the transaction hashes of the block
+func (ec *Client) blockTxHashes(ctx context.Context, blockRef rpc.BlockNumberOrHash) (*types.Header, *rpcBlockTxHashes, error) {
+	var (
+		raw json.RawMessage
+		err error
+	)
+	if hash, ok := blockRef.Hash(); ok {
+		err = ec.c.CallContext(ctx, &raw, "eth_getBlockByHash", hash, false)
+	} else if number, ok := blockRef.Number(); ok {
+		var arg []byte
+		arg, err = number.MarshalText()
+		if err != nil {
+			return nil, nil, err
+		}
+		err = ec.c.CallContext(ctx, &raw, "eth_getBlockByNumber", string(arg), false)
+	} else {
+		return nil, nil, errors.New("block reference has neither number nor hash")
+	}
+	if err != nil {
+		return nil, nil, err
+	} else if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil, ethereum.NotFound
+	}
+
+	var (
+		header *types.Header
+		body   rpcBlockTxHashes
+	)
+	if err = json.Unmarshal(raw, &header); err != nil {
+		return nil, nil, err
+	}
+	if err = json.Unmarshal(raw, &body); err != nil {
+		return nil, nil, err
+	}
+	return header, &body, nil
+}
+
+func (ec *Client) batchReceipts(ctx context.Context, txHashes []common.Hash) ([]*types.Receipt, error) {
+	receipts := make([]*types.Receipt, len(txHashes))
+	for start := 0; start < len(txHashes); start += receiptBatchSize {
+		end := start + receiptBatchSize
+		if end > len(txHashes) {
+			end = len(txHashes)
+		}
+		reqs := make([]rpc.BatchElem, end-start)
+		for i := range reqs {
+			reqs[i] = rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{txHashes[start+i]},
+				Result: &receipts[start+i],
+			}
+		}
+		if err := ec.c.BatchCallContext(ctx, reqs); err != nil {
+			return nil, err
+		}
+		for i := range reqs {
+			if reqs[i].Error != nil {
+				return nil, errors.WithMessagef(reqs[i].Error, "receipt of %s", txHashes[start+i])
+			}
+		}
+	}
+	return receipts, nil
+}
+
+// isMethodNotFound reports whether err means the node does not serve the method
+func isMethodNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601 {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "method not found") || strings.Contains(msg, "does not exist") ||
+		strings.Contains(msg, "not supported")
+}
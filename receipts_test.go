@@ -0,0 +1,136 @@
+package ethclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+type receiptsBlock struct {
+	hash     common.Hash
+	header   *types.Header
+	receipts []*types.Receipt
+}
+
+// typedReceipts encodes dynamic fee receipts with the type byte of txType, the consensus encoding
+// of every typed receipt
+type typedReceipts struct {
+	types.Receipts
+	txType byte
+}
+
+func (rs typedReceipts) EncodeIndex(i int, w *bytes.Buffer) {
+	receipt := *rs.Receipts[i]
+	receipt.Type = types.DynamicFeeTxType
+	var buf bytes.Buffer
+	types.Receipts{&receipt}.EncodeIndex(0, &buf)
+	w.WriteByte(rs.txType)
+	w.Write(buf.Bytes()[1:])
+}
+
+func newReceiptsBlock(txType byte) *receiptsBlock {
+	var receipts []*types.Receipt
+	for i := 0; i < 3; i++ {
+		receipts = append(receipts, &types.Receipt{
+			Type:              txType,
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: uint64(21000 * (i + 1)),
+			Logs:              []*types.Log{},
+			TxHash:            common.Hash{byte(i + 1)},
+			GasUsed:           21000,
+		})
+	}
+	header := &types.Header{
+		Number:      big.NewInt(10),
+		Difficulty:  new(big.Int),
+		ReceiptHash: types.DeriveSha(typedReceipts{receipts, txType}, trie.NewStackTrie(nil)),
+	}
+	return &receiptsBlock{hash: header.Hash(), header: header, receipts: receipts}
+}
+
+// fallbackReceiptsService does not serve eth_getBlockReceipts
+type fallbackReceiptsService struct {
+	block *receiptsBlock
+}
+
+func (s *fallbackReceiptsService) GetBlockByNumber(number string, full bool) (json.RawMessage, error) {
+	var fields map[string]interface{}
+	data, _ := json.Marshal(s.block.header)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	txs := make([]common.Hash, len(s.block.receipts))
+	for i, receipt := range s.block.receipts {
+		txs[i] = receipt.TxHash
+	}
+	fields["hash"] = s.block.hash
+	fields["transactions"] = txs
+	return json.Marshal(fields)
+}
+
+func (s *fallbackReceiptsService) GetTransactionReceipt(hash common.Hash) *types.Receipt {
+	for _, receipt := range s.block.receipts {
+		if receipt.TxHash == hash {
+			return receipt
+		}
+	}
+	return nil
+}
+
+type receiptsService struct {
+	fallbackReceiptsService
+}
+
+func (s *receiptsService) GetBlockReceipts(hash common.Hash) []*types.Receipt {
+	if hash != s.block.hash {
+		return nil
+	}
+	return s.block.receipts
+}
+
+func TestBlockReceipts(t *testing.T) {
+	// blob transaction receipts, type 3, are unknown to types.Receipts of go-ethereum v1.10.25
+	for _, txType := range []byte{types.DynamicFeeTxType, 3} {
+		testBlockReceipts(t, newReceiptsBlock(txType))
+	}
+}
+
+func testBlockReceipts(t *testing.T, block *receiptsBlock) {
+	services := map[string]interface{}{
+		"eth_getBlockReceipts": &receiptsService{fallbackReceiptsService{block}},
+		"fallback":             &fallbackReceiptsService{block},
+	}
+	for name, service := range services {
+		server := rpc.NewServer()
+		if err := server.RegisterName("eth", service); err != nil {
+			t.Fatal(err)
+		}
+		ec := NewClient(rpc.DialInProc(server))
+
+		ref := rpc.BlockNumberOrHashWithNumber(10)
+		receipts, err := ec.BlockReceipts(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(receipts) != 3 || receipts[2].TxHash != (common.Hash{3}) || receipts[2].CumulativeGasUsed != 63000 {
+			t.Errorf("%s: unexpected receipts %+v", name, receipts)
+		}
+
+		block.receipts[1].Status = types.ReceiptStatusFailed
+		if _, err = ec.BlockReceipts(context.Background(), ref); !errors.Is(err, ErrReceiptsMismatch) {
+			t.Errorf("%s: altered receipt accepted: %v", name, err)
+		}
+		block.receipts[1].Status = types.ReceiptStatusSuccessful
+
+		ec.Close()
+		server.Stop()
+	}
+}
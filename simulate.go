@@ -0,0 +1,152 @@
+package ethclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrTxWillFail = errors.New("transaction will fail")
+
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+	panicReasons  = map[uint64]string{
+		0x00: "generic panic",
+		0x01: "assert failed",
+		0x11: "arithmetic overflow or underflow",
+		0x12: "division or modulo by zero",
+		0x21: "invalid enum value",
+		0x22: "invalid storage byte array",
+		0x31: "pop on empty array",
+		0x32: "array index out of bounds",
+		0x41: "out of memory",
+		0x51: "call to zero initialized function",
+	}
+	// executionErrors are the messages of EVM errors returned by nodes without revert data
+	executionErrors = []string{
+		"execution reverted", "out of gas", "gas required exceeds allowance", "invalid opcode",
+		"invalid jump destination", "stack underflow", "stack limit reached", "write protection",
+	}
+)
+
+// SimulationError is returned when the simulation of a transaction fails, it matches ErrTxWillFail with errors.Is.
+// Reason is the decoded revert reason and Data the raw revert data, both empty if the node returns none.
+type SimulationError struct {
+	Reason string
+	Data   []byte
+	Err    error
+}
+
+func (e *SimulationError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%s: %s", ErrTxWillFail, e.Reason)
+	}
+	return fmt.Sprintf("%s: %v", ErrTxWillFail, e.Err)
+}
+
+func (e *SimulationError) Unwrap() error {
+	return e.Err
+}
+
+func (e *SimulationError) Is(target error) bool {
+	return target == ErrTxWillFail
+}
+
+// DecodeRevert decode the revert data of Error(string) and Panic(uint256)
+func DecodeRevert(data []byte) (string, error) {
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason, nil
+	}
+	if len(data) == 36 && string(data[:4]) == string(panicSelector) {
+		code := new(big.Int).SetBytes(data[4:])
+		reason, ok := panicReasons[code.Uint64()]
+		if !ok || !code.IsUint64() {
+			reason = "unknown panic"
+		}
+		return fmt.Sprintf("panic 0x%x: %s", code, reason), nil
+	}
+	return "", errors.New("unknown revert data")
+}
+
+// SetSimulate configure BuildContractTx and BuildTransferTx to simulate transactions before signing them,
+// transactions certain to fail are refused with a SimulationError, see SimulateTx
+func (ec *Client) SetSimulate(simulate bool) {
+	ec.simulate = simulate
+}
+
+// SimulateTx execute msg with eth_call on the pending block, msg should carry the gas limit and fees of the
+// transaction. The gas needed is estimated and compared with msg.Gas when it is set.
+// Execution failures are returned as SimulationError, transport and other node errors unchanged.
+func (ec *Client) SimulateTx(ctx context.Context, msg ethereum.CallMsg) error {
+	return ec.simulateTx(ctx, msg, msg.Gas != 0)
+}
+
+func (ec *Client) simulateTx(ctx context.Context, msg ethereum.CallMsg, checkGas bool) error {
+	if _, err := ec.PendingCallContract(ctx, msg); err != nil {
+		return newSimulationError(err)
+	}
+	if !checkGas {
+		return nil
+	}
+
+	estimateMsg := msg
+	estimateMsg.Gas = 0
+	gas, err := ec.EstimateGas(ctx, estimateMsg)
+	if err != nil {
+		return newSimulationError(err)
+	}
+	if gas > msg.Gas {
+		return &SimulationError{
+			Reason: fmt.Sprintf("gas limit %d is below the %d gas needed", msg.Gas, gas),
+			Err:    errors.New("out of gas"),
+		}
+	}
+	return nil
+}
+
+// IsExecutionError reports whether err is the failure of the EVM execution of a call, a revert or an
+// exceptional halt reported by the node. Transport errors and other node errors are not.
+func IsExecutionError(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	// geth reports reverts with code 3, every json-rpc error is a DataError but only reverts carry data
+	var dataErr rpc.DataError
+	if rpcErr.ErrorCode() == 3 || (errors.As(err, &dataErr) && dataErr.ErrorData() != nil) {
+		return true
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	for _, execErr := range executionErrors {
+		if strings.Contains(msg, execErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// newSimulationError wraps the execution errors in a SimulationError, other errors are returned unchanged
+func newSimulationError(err error) error {
+	if !IsExecutionError(err) {
+		return err
+	}
+	simErr := &SimulationError{Err: err}
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return simErr
+	}
+	if hexData, ok := dataErr.ErrorData().(string); ok {
+		simErr.Data, _ = hexutil.Decode(hexData)
+	}
+	if reason, decodeErr := DecodeRevert(simErr.Data); decodeErr == nil {
+		simErr.Reason = reason
+	}
+	return simErr
+}
@@ -0,0 +1,162 @@
+package ethclient
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// revertData is Error("Too little received")
+const revertData = "0x08c379a000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000013546f6f206c6974746c6520726563656976656400000000000000000000000000"
+
+type revertError struct{}
+
+func (revertError) Error() string          { return "execution reverted" }
+func (revertError) ErrorCode() int         { return 3 }
+func (revertError) ErrorData() interface{} { return revertData }
+
+type simulateService struct {
+	revert bool
+	fail   error
+	gas    uint64
+}
+
+func (s *simulateService) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	if s.revert {
+		return nil, revertError{}
+	}
+	if s.fail != nil {
+		return nil, s.fail
+	}
+	return hexutil.Bytes{}, nil
+}
+
+func (s *simulateService) EstimateGas(args map[string]interface{}) hexutil.Uint64 {
+	return hexutil.Uint64(s.gas)
+}
+
+func TestDecodeRevert(t *testing.T) {
+	reason, err := DecodeRevert(hexutil.MustDecode(revertData))
+	if err != nil || reason != "Too little received" {
+		t.Errorf("reason %q error %v", reason, err)
+	}
+	panicData := append([]byte{0x4e, 0x48, 0x7b, 0x71}, common.LeftPadBytes([]byte{0x11}, 32)...)
+	reason, err = DecodeRevert(panicData)
+	if err != nil || reason != "panic 0x11: arithmetic overflow or underflow" {
+		t.Errorf("reason %q error %v", reason, err)
+	}
+	if _, err = DecodeRevert([]byte{1, 2, 3, 4}); err == nil {
+		t.Error("unknown revert data decoded")
+	}
+}
+
+func TestSimulateTx(t *testing.T) {
+	service := &simulateService{revert: true, gas: 60000}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+
+	to := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	msg := ethereum.CallMsg{To: &to, Gas: 50000, Value: big.NewInt(1)}
+	err := ec.SimulateTx(context.Background(), msg)
+	var simErr *SimulationError
+	if !errors.Is(err, ErrTxWillFail) || !errors.As(err, &simErr) || simErr.Reason != "Too little received" {
+		t.Errorf("unexpected simulation error %v", err)
+	}
+
+	service.revert = false
+	err = ec.SimulateTx(context.Background(), msg)
+	if !errors.Is(err, ErrTxWillFail) {
+		t.Errorf("gas limit below estimate not refused: %v", err)
+	}
+
+	service.gas = 45000
+	if err = ec.SimulateTx(context.Background(), msg); err != nil {
+		t.Errorf("unexpected simulation error %v", err)
+	}
+}
+
+func TestSimulateTxNodeError(t *testing.T) {
+	to := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	msg := ethereum.CallMsg{To: &to}
+
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	c, err := rpc.DialHTTP(unavailable.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(c)
+	defer ec.Close()
+	if err = ec.SimulateTx(context.Background(), msg); err == nil || errors.Is(err, ErrTxWillFail) {
+		t.Errorf("transport error reported as %v", err)
+	}
+
+	service := &simulateService{fail: errors.New("header not found")}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err = server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	ec = NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+	if err = ec.SimulateTx(context.Background(), msg); err == nil || errors.Is(err, ErrTxWillFail) {
+		t.Errorf("node error reported as %v", err)
+	}
+
+	service.fail = errors.New("invalid opcode: INVALID")
+	if err = ec.SimulateTx(context.Background(), msg); !errors.Is(err, ErrTxWillFail) {
+		t.Errorf("execution error reported as %v", err)
+	}
+}
+
+type transferService struct {
+	simulateService
+	gasPriceService
+}
+
+func (s *transferService) GetTransactionCount(address common.Address, block string) hexutil.Uint64 {
+	return 0
+}
+
+func TestBuildTransferTxGasLimit(t *testing.T) {
+	// the recipient is a contract needing more than the intrinsic gas
+	service := &transferService{
+		simulateService: simulateService{gas: 30000},
+		gasPriceService: gasPriceService{baseFee: big.NewInt(10e9), tip: big.NewInt(1e9)},
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+	ec.SetSimulate(true)
+
+	to := "0x6B175474E89094C44Da98b954EedeAC495271d0F"
+	_, err := ec.BuildTransferTx(hex.EncodeToString(crypto.FromECDSA(testKey)), to, &bind.TransactOpts{Value: big.NewInt(1)})
+	if !errors.Is(err, ErrTxWillFail) {
+		t.Errorf("transfer without gas limit not refused: %v", err)
+	}
+	if _, err = ec.BuildTransferTx(hex.EncodeToString(crypto.FromECDSA(testKey)), to, &bind.TransactOpts{Value: big.NewInt(1), GasLimit: 20000}); err == nil {
+		t.Error("gas limit below intrinsic gas accepted")
+	}
+}
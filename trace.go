@@ -0,0 +1,161 @@
+package ethclient
+
+import (
+	"context"
+	"math/big"
+
+	erc20 "github.com/ackermanx/ethclient/abi"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Built-in tracers of debug_traceTransaction and debug_traceCall
+const (
+	CallTracer     = "callTracer"
+	PrestateTracer = "prestateTracer"
+)
+
+// TraceConfig is the config of debug_traceTransaction and debug_traceCall
+type TraceConfig struct {
+	Tracer       string      `json:"tracer,omitempty"`
+	Timeout      string      `json:"timeout,omitempty"`
+	TracerConfig interface{} `json:"tracerConfig,omitempty"`
+}
+
+// CallFrame is a call of the callTracer result, Calls are the nested calls
+type CallFrame struct {
+	Type         string          `json:"type"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to,omitempty"`
+	Value        *hexutil.Big    `json:"value,omitempty"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Calls        []CallFrame     `json:"calls,omitempty"`
+}
+
+// PrestateAccount is an account of the prestateTracer result
+type PrestateAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// TraceTransaction trace transaction txHash with config and decode the tracer output into result
+func (ec *Client) TraceTransaction(ctx context.Context, txHash common.Hash, config *TraceConfig, result interface{}) error {
+	return ec.c.CallContext(ctx, result, "debug_traceTransaction", txHash, config)
+}
+
+// TraceCall trace msg on top of the block blockNumber with config and decode the tracer output into result
+func (ec *Client) TraceCall(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int, config *TraceConfig, result interface{}) error {
+	return ec.c.CallContext(ctx, result, "debug_traceCall", toCallArg(msg), toBlockNumArg(blockNumber), config)
+}
+
+// TraceTransactionCallTree trace transaction txHash with callTracer
+func (ec *Client) TraceTransactionCallTree(ctx context.Context, txHash common.Hash) (*CallFrame, error) {
+	var frame CallFrame
+	if err := ec.TraceTransaction(ctx, txHash, &TraceConfig{Tracer: CallTracer}, &frame); err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
+
+// TraceCallTree trace msg with callTracer
+func (ec *Client) TraceCallTree(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (*CallFrame, error) {
+	var frame CallFrame
+	if err := ec.TraceCall(ctx, msg, blockNumber, &TraceConfig{Tracer: CallTracer}, &frame); err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
+
+// TraceTransactionPrestate trace transaction txHash with prestateTracer, returns the accounts touched
+// by the transaction in their state before it
+func (ec *Client) TraceTransactionPrestate(ctx context.Context, txHash common.Hash) (map[common.Address]PrestateAccount, error) {
+	var accounts map[common.Address]PrestateAccount
+	if err := ec.TraceTransaction(ctx, txHash, &TraceConfig{Tracer: PrestateTracer}, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// TraceCallPrestate trace msg with prestateTracer
+func (ec *Client) TraceCallPrestate(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (map[common.Address]PrestateAccount, error) {
+	var accounts map[common.Address]PrestateAccount
+	if err := ec.TraceCall(ctx, msg, blockNumber, &TraceConfig{Tracer: PrestateTracer}, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// DecodedCall is a call frame labelled with its method and decoded arguments
+type DecodedCall struct {
+	Frame *CallFrame
+	// Method is the name of the called method, empty if no abi of the registry matches the input
+	Method  string
+	Args    map[string]interface{}
+	Outputs map[string]interface{}
+	// RevertReason is the decoded revert reason of failed calls
+	RevertReason string
+	Calls        []*DecodedCall
+}
+
+// DecodeCallTree label the frames of a callTracer result with the abis of abis. Frames are decoded by the abi
+// stored for their address, or by any abi of abis with a method matching the selector.
+func DecodeCallTree(frame *CallFrame, abis *erc20.AddrAbiMap) *DecodedCall {
+	decoded := &DecodedCall{Frame: frame, RevertReason: frame.RevertReason}
+	if frame.Error != "" && decoded.RevertReason == "" {
+		decoded.RevertReason, _ = DecodeRevert(frame.Output)
+	}
+	if method := lookupMethod(frame, abis); method != nil {
+		decoded.Method = method.Name
+		args := make(map[string]interface{})
+		if err := method.Inputs.UnpackIntoMap(args, frame.Input[4:]); err == nil {
+			decoded.Args = args
+		}
+		if frame.Error == "" && len(frame.Output) > 0 {
+			outputs := make(map[string]interface{})
+			if err := method.Outputs.UnpackIntoMap(outputs, frame.Output); err == nil {
+				decoded.Outputs = outputs
+			}
+		}
+	}
+
+	decoded.Calls = make([]*DecodedCall, len(frame.Calls))
+	for i := range frame.Calls {
+		decoded.Calls[i] = DecodeCallTree(&frame.Calls[i], abis)
+	}
+	return decoded
+}
+
+// DecodeCallTree label the frames of a callTracer result with the abis cached by Call and BuildContractTx
+func (ec *Client) DecodeCallTree(frame *CallFrame) *DecodedCall {
+	return DecodeCallTree(frame, &ec.parsedAbis)
+}
+
+func lookupMethod(frame *CallFrame, abis *erc20.AddrAbiMap) (method *abi.Method) {
+	if len(frame.Input) < 4 || abis == nil {
+		return nil
+	}
+	if frame.To != nil {
+		if parsedAbi, ok := abis.Load(*frame.To); ok {
+			if method, err := parsedAbi.MethodById(frame.Input[:4]); err == nil {
+				return method
+			}
+		}
+	}
+	abis.Range(func(_ common.Address, parsedAbi abi.ABI) bool {
+		if m, err := parsedAbi.MethodById(frame.Input[:4]); err == nil {
+			method = m
+			return false
+		}
+		return true
+	})
+	return
+}
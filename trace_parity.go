@@ -0,0 +1,213 @@
+package ethclient
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// TraceAction is the action of a trace_* trace. Calls set CallType, From, To, Gas, Input and Value, creates set
+// From, Gas, Init and Value, suicides set Address, RefundAddress and Balance, rewards set Author, RewardType and Value.
+type TraceAction struct {
+	CallType      string          `json:"callType,omitempty"`
+	From          *common.Address `json:"from,omitempty"`
+	To            *common.Address `json:"to,omitempty"`
+	Gas           hexutil.Uint64  `json:"gas,omitempty"`
+	Input         hexutil.Bytes   `json:"input,omitempty"`
+	Init          hexutil.Bytes   `json:"init,omitempty"`
+	Value         *hexutil.Big    `json:"value,omitempty"`
+	Address       *common.Address `json:"address,omitempty"`
+	RefundAddress *common.Address `json:"refundAddress,omitempty"`
+	Balance       *hexutil.Big    `json:"balance,omitempty"`
+	Author        *common.Address `json:"author,omitempty"`
+	RewardType    string          `json:"rewardType,omitempty"`
+}
+
+// TraceResult is the result of a trace_* trace, creates set Address and Code instead of Output
+type TraceResult struct {
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Output  hexutil.Bytes   `json:"output,omitempty"`
+	Address *common.Address `json:"address,omitempty"`
+	Code    hexutil.Bytes   `json:"code,omitempty"`
+}
+
+// Trace is a Parity/OpenEthereum style trace, Type is one of call, create, suicide and reward
+type Trace struct {
+	Action              TraceAction  `json:"action"`
+	BlockHash           *common.Hash `json:"blockHash,omitempty"`
+	BlockNumber         uint64       `json:"blockNumber"`
+	Result              *TraceResult `json:"result,omitempty"`
+	Error               string       `json:"error,omitempty"`
+	Subtraces           int          `json:"subtraces"`
+	TraceAddress        []int        `json:"traceAddress"`
+	TransactionHash     *common.Hash `json:"transactionHash,omitempty"`
+	TransactionPosition *uint64      `json:"transactionPosition,omitempty"`
+	Type                string       `json:"type"`
+}
+
+// TraceReplay is a transaction result of trace_replayBlockTransactions, StateDiff and VmTrace are
+// left raw and only set when requested
+type TraceReplay struct {
+	Output          hexutil.Bytes          `json:"output"`
+	Trace           []Trace                `json:"trace"`
+	StateDiff       map[string]interface{} `json:"stateDiff,omitempty"`
+	VmTrace         map[string]interface{} `json:"vmTrace,omitempty"`
+	TransactionHash common.Hash            `json:"transactionHash"`
+}
+
+// TraceFilter is the filter of trace_filter, nil blocks and empty addresses are not filtered.
+// Nil blocks are left out of the request, nodes then search from the genesis to the latest block.
+type TraceFilter struct {
+	FromBlock   *big.Int
+	ToBlock     *big.Int
+	FromAddress []common.Address
+	ToAddress   []common.Address
+	// After skips the first traces and Count limits the number of traces, zero for no limit
+	After uint64
+	Count uint64
+}
+
+func toTraceFilterArg(filter TraceFilter) interface{} {
+	arg := map[string]interface{}{}
+	// toBlockNumArg would turn nil into latest and restrict the search to the latest block
+	if filter.FromBlock != nil {
+		arg["fromBlock"] = toBlockNumArg(filter.FromBlock)
+	}
+	if filter.ToBlock != nil {
+		arg["toBlock"] = toBlockNumArg(filter.ToBlock)
+	}
+	if len(filter.FromAddress) > 0 {
+		arg["fromAddress"] = filter.FromAddress
+	}
+	if len(filter.ToAddress) > 0 {
+		arg["toAddress"] = filter.ToAddress
+	}
+	if filter.After > 0 {
+		arg["after"] = filter.After
+	}
+	if filter.Count > 0 {
+		arg["count"] = filter.Count
+	}
+	return arg
+}
+
+// TraceBlock returns the traces of all transactions and rewards of block number, nil number is the latest block
+func (ec *Client) TraceBlock(ctx context.Context, number *big.Int) ([]Trace, error) {
+	var traces []Trace
+	err := ec.c.CallContext(ctx, &traces, "trace_block", toBlockNumArg(number))
+	return traces, err
+}
+
+// TraceTransactionTraces returns the traces of transaction txHash
+func (ec *Client) TraceTransactionTraces(ctx context.Context, txHash common.Hash) ([]Trace, error) {
+	var traces []Trace
+	err := ec.c.CallContext(ctx, &traces, "trace_transaction", txHash)
+	return traces, err
+}
+
+// TraceFilter returns the traces matching filter
+func (ec *Client) TraceFilter(ctx context.Context, filter TraceFilter) ([]Trace, error) {
+	var traces []Trace
+	err := ec.c.CallContext(ctx, &traces, "trace_filter", toTraceFilterArg(filter))
+	return traces, err
+}
+
+// TraceReplayBlockTransactions replay the transactions of block number with traceTypes, any of
+// "trace", "stateDiff" and "vmTrace"
+func (ec *Client) TraceReplayBlockTransactions(ctx context.Context, number *big.Int, traceTypes ...string) ([]TraceReplay, error) {
+	if len(traceTypes) == 0 {
+		traceTypes = []string{"trace"}
+	}
+	var replays []TraceReplay
+	err := ec.c.CallContext(ctx, &replays, "trace_replayBlockTransactions", toBlockNumArg(number), traceTypes)
+	return replays, err
+}
+
+// ValueTransfer is a native currency transfer of a trace, including internal transfers by contracts
+type ValueTransfer struct {
+	BlockNumber     uint64
+	TransactionHash common.Hash
+	TraceAddress    []int
+	// Type is the trace type, call, create or suicide
+	Type  string
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+}
+
+// ValueTransfers returns the transfers with non zero value of traces. Traces which failed and their
+// sub traces are skipped, as well as delegate and static calls and block rewards.
+func ValueTransfers(traces []Trace) []ValueTransfer {
+	transfers := make([]ValueTransfer, 0)
+	failed := make(map[common.Hash][][]int)
+	for _, trace := range traces {
+		var txHash common.Hash
+		if trace.TransactionHash != nil {
+			txHash = *trace.TransactionHash
+		}
+		if trace.Error != "" {
+			failed[txHash] = append(failed[txHash], trace.TraceAddress)
+			continue
+		}
+		if underFailedTrace(failed[txHash], trace.TraceAddress) {
+			continue
+		}
+
+		transfer := ValueTransfer{BlockNumber: trace.BlockNumber, TransactionHash: txHash, TraceAddress: trace.TraceAddress, Type: trace.Type}
+		action := trace.Action
+		switch trace.Type {
+		case "call":
+			if (action.CallType != "" && action.CallType != "call") || action.From == nil || action.To == nil || action.Value == nil {
+				continue
+			}
+			transfer.From, transfer.To, transfer.Value = *action.From, *action.To, action.Value.ToInt()
+		case "create":
+			if action.From == nil || action.Value == nil || trace.Result == nil || trace.Result.Address == nil {
+				continue
+			}
+			transfer.From, transfer.To, transfer.Value = *action.From, *trace.Result.Address, action.Value.ToInt()
+		case "suicide":
+			if action.Address == nil || action.RefundAddress == nil || action.Balance == nil {
+				continue
+			}
+			transfer.From, transfer.To, transfer.Value = *action.Address, *action.RefundAddress, action.Balance.ToInt()
+		default:
+			continue
+		}
+		if transfer.Value.Sign() > 0 {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers
+}
+
+// underFailedTrace reports whether traceAddress is a sub trace of one of failed
+func underFailedTrace(failed [][]int, traceAddress []int) bool {
+	for _, parent := range failed {
+		if len(parent) > len(traceAddress) {
+			continue
+		}
+		match := true
+		for i := range parent {
+			if parent[i] != traceAddress[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// BlockValueTransfers returns the native currency transfers of block number from trace_block, see ValueTransfers
+func (ec *Client) BlockValueTransfers(ctx context.Context, number *big.Int) ([]ValueTransfer, error) {
+	traces, err := ec.TraceBlock(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return ValueTransfers(traces), nil
+}
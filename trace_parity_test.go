@@ -0,0 +1,84 @@
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const blockTraces = `[
+	{"action":{"callType":"call","from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","gas":"0x0","input":"0x","value":"0x64"},"blockNumber":10,"result":{"gasUsed":"0x0","output":"0x"},"subtraces":2,"traceAddress":[],"transactionHash":"0x0100000000000000000000000000000000000000000000000000000000000000","transactionPosition":0,"type":"call"},
+	{"action":{"callType":"call","from":"0x0000000000000000000000000000000000000002","to":"0x0000000000000000000000000000000000000003","gas":"0x0","input":"0x","value":"0x32"},"blockNumber":10,"result":{"gasUsed":"0x0","output":"0x"},"subtraces":0,"traceAddress":[0],"transactionHash":"0x0100000000000000000000000000000000000000000000000000000000000000","transactionPosition":0,"type":"call"},
+	{"action":{"callType":"call","from":"0x0000000000000000000000000000000000000002","to":"0x0000000000000000000000000000000000000004","gas":"0x0","input":"0x","value":"0x10"},"blockNumber":10,"error":"Reverted","subtraces":1,"traceAddress":[1],"transactionHash":"0x0100000000000000000000000000000000000000000000000000000000000000","transactionPosition":0,"type":"call"},
+	{"action":{"callType":"call","from":"0x0000000000000000000000000000000000000004","to":"0x0000000000000000000000000000000000000005","gas":"0x0","input":"0x","value":"0x8"},"blockNumber":10,"result":{"gasUsed":"0x0","output":"0x"},"subtraces":0,"traceAddress":[1,0],"transactionHash":"0x0100000000000000000000000000000000000000000000000000000000000000","transactionPosition":0,"type":"call"},
+	{"action":{"callType":"delegatecall","from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000006","gas":"0x0","input":"0x","value":"0x64"},"blockNumber":10,"result":{"gasUsed":"0x0","output":"0x"},"subtraces":0,"traceAddress":[0],"transactionHash":"0x0200000000000000000000000000000000000000000000000000000000000000","transactionPosition":1,"type":"call"},
+	{"action":{"address":"0x0000000000000000000000000000000000000007","refundAddress":"0x0000000000000000000000000000000000000008","balance":"0x5"},"blockNumber":10,"subtraces":0,"traceAddress":[1],"transactionHash":"0x0200000000000000000000000000000000000000000000000000000000000000","transactionPosition":1,"type":"suicide"},
+	{"action":{"author":"0x0000000000000000000000000000000000000009","rewardType":"block","value":"0x1bc16d674ec80000"},"blockNumber":10,"subtraces":0,"traceAddress":[],"type":"reward"}
+]`
+
+type traceService struct{}
+
+func (traceService) Block(number string) json.RawMessage {
+	return json.RawMessage(blockTraces)
+}
+
+func TestBlockValueTransfers(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("trace", traceService{}); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+
+	transfers, err := ec.BlockValueTransfers(context.Background(), big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		from, to byte
+		value    int64
+	}{{1, 2, 100}, {2, 3, 50}, {7, 8, 5}}
+	if len(transfers) != len(expected) {
+		t.Fatalf("got %d transfers, want %d: %+v", len(transfers), len(expected), transfers)
+	}
+	for i, e := range expected {
+		transfer := transfers[i]
+		if transfer.From != common.BytesToAddress([]byte{e.from}) || transfer.To != common.BytesToAddress([]byte{e.to}) || transfer.Value.Int64() != e.value {
+			t.Errorf("transfer %d is %+v, want %d -> %d value %d", i, transfer, e.from, e.to, e.value)
+		}
+	}
+	if transfers[1].TransactionHash != common.HexToHash("0x0100000000000000000000000000000000000000000000000000000000000000") || len(transfers[1].TraceAddress) != 1 {
+		t.Errorf("unexpected internal transfer %+v", transfers[1])
+	}
+}
+
+func TestToTraceFilterArg(t *testing.T) {
+	data, err := json.Marshal(toTraceFilterArg(TraceFilter{
+		FromBlock: big.NewInt(1),
+		ToAddress: []common.Address{common.BytesToAddress([]byte{1})},
+		Count:     10,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"count":10,"fromBlock":"0x1","toAddress":["0x0000000000000000000000000000000000000001"]}`
+	if string(data) != expected {
+		t.Errorf("got %s, want %s", data, expected)
+	}
+
+	data, err = json.Marshal(toTraceFilterArg(TraceFilter{
+		ToAddress: []common.Address{common.BytesToAddress([]byte{1})},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"toAddress":["0x0000000000000000000000000000000000000001"]}`
+	if string(data) != expected {
+		t.Errorf("got %s, want %s", data, expected)
+	}
+}
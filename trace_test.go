@@ -0,0 +1,76 @@
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	erc20 "github.com/ackermanx/ethclient/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const callTrace = `{
+	"type": "CALL",
+	"from": "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+	"to": "0x1111111111111111111111111111111111111111",
+	"value": "0x0",
+	"gas": "0x30d40",
+	"gasUsed": "0x1d4c0",
+	"input": "0x12345678",
+	"error": "execution reverted",
+	"output": "0x08c379a000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000013546f6f206c6974746c6520726563656976656400000000000000000000000000",
+	"calls": [{
+		"type": "CALL",
+		"from": "0x1111111111111111111111111111111111111111",
+		"to": "0x6b175474e89094c44da98b954eedeac495271d0f",
+		"gas": "0x1d4c0",
+		"gasUsed": "0x5208",
+		"input": "0xa9059cbb0000000000000000000000005aaeb6053f3e94c9b9a09f33669435e7ef1beaed00000000000000000000000000000000000000000000000000000000000003e8",
+		"output": "0x0000000000000000000000000000000000000000000000000000000000000001"
+	}]
+}`
+
+type debugService struct{}
+
+func (debugService) TraceTransaction(hash common.Hash, config *TraceConfig) (json.RawMessage, error) {
+	return json.RawMessage(callTrace), nil
+}
+
+func TestDecodeCallTree(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("debug", debugService{}); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+
+	frame, err := ec.TraceTransactionCallTree(context.Background(), common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frame.Calls) != 1 || uint64(frame.Calls[0].GasUsed) != 21000 {
+		t.Fatalf("unexpected call tree %+v", frame)
+	}
+
+	dai := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	parsedAbi, err := abi.JSON(strings.NewReader(erc20.ERC20Abi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	abis := &erc20.AddrAbiMap{}
+	abis.Store(dai, parsedAbi)
+
+	decoded := DecodeCallTree(frame, abis)
+	if decoded.Method != "" || decoded.RevertReason != "Too little received" {
+		t.Errorf("root call decoded as %q reason %q", decoded.Method, decoded.RevertReason)
+	}
+	transfer := decoded.Calls[0]
+	if transfer.Method != "transfer" || transfer.Args["value"].(*big.Int).Int64() != 1000 || transfer.Outputs[""] != true {
+		t.Errorf("transfer decoded as %q %v %v", transfer.Method, transfer.Args, transfer.Outputs)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"math/big"
 
+	"github.com/ackermanx/ethclient"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -42,7 +43,15 @@ var (
 // CalculatePoolAddressV2 calculate uniswapV2 pool address offline from pool tokens
 func CalculatePoolAddressV2(token0, token1 string) (pairAddress common.Address, err error) {
 	factoryAddr := common.HexToAddress(FactoryAddrV2)
-	tkn0, tkn1 := sortAddressess(common.HexToAddress(token0), common.HexToAddress(token1))
+	tknA, err := ethclient.ParseAddress(token0)
+	if err != nil {
+		return
+	}
+	tknB, err := ethclient.ParseAddress(token1)
+	if err != nil {
+		return
+	}
+	tkn0, tkn1 := sortAddressess(tknA, tknB)
 
 	msg := []byte{255}
 	msg = append(msg, factoryAddr.Bytes()...)
@@ -70,7 +79,15 @@ func sortAddressess(tkn0, tkn1 common.Address) (common.Address, common.Address)
 
 // CalculatePoolAddressV3 calculate uniswapV3 pool address offline from pool tokens and fee
 func CalculatePoolAddressV3(tokenA, tokenB string, fee *big.Int) (poolAddress common.Address, err error) {
-	tkn0, tkn1 := sortAddressess(common.HexToAddress(tokenA), common.HexToAddress(tokenB))
+	tknA, err := ethclient.ParseAddress(tokenA)
+	if err != nil {
+		return
+	}
+	tknB, err := ethclient.ParseAddress(tokenB)
+	if err != nil {
+		return
+	}
+	tkn0, tkn1 := sortAddressess(tknA, tknB)
 	paramsPacked, err := saltAbiArguments.Pack(tkn0, tkn1, fee)
 	if err != nil {
 		err = errors.Wrap(err, "pack arguments")
@@ -109,3 +126,28 @@ func CalculatePoolAddress(tokenA, tokenB, factoryAddr common.Address, poolInitCo
 	pairAddressBytes = pairAddressBytes.Abs(pairAddressBytes)
 	return common.BytesToAddress(pairAddressBytes.Bytes()), nil
 }
+
+// CalculatePoolAddressV3Like calculate uniswapV3 like pool address from tokens, fee, pool deployer address and pool init code
+func CalculatePoolAddressV3Like(tokenA, tokenB common.Address, fee *big.Int, deployerAddr common.Address, poolInitCodeStr string) (poolAddr common.Address, err error) {
+	poolInitCode, err := hex.DecodeString(poolInitCodeStr)
+	if err != nil {
+		err = errors.Wrap(err, "decode pool init code failed")
+		return
+	}
+
+	tkn0, tkn1 := sortAddressess(tokenA, tokenB)
+	paramsPacked, err := saltAbiArguments.Pack(tkn0, tkn1, fee)
+	if err != nil {
+		err = errors.Wrap(err, "pack arguments")
+		return
+	}
+
+	salt := crypto.Keccak256(paramsPacked)
+	msg := []byte{255}
+	msg = append(msg, deployerAddr.Bytes()...)
+	msg = append(msg, salt...)
+	msg = append(msg, poolInitCode...)
+
+	hash := crypto.Keccak256(msg)
+	return common.BytesToAddress(hash[12:]), nil
+}
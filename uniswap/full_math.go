@@ -0,0 +1,38 @@
+package uniswap
+
+import (
+	"math/big"
+)
+
+var (
+	Q96  = new(big.Int).Lsh(big.NewInt(1), 96)
+	Q128 = new(big.Int).Lsh(big.NewInt(1), 128)
+
+	maxUint160 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+	maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+)
+
+// mulDiv calculates floor(a*b/denominator) with full precision, like FullMath.mulDiv
+func mulDiv(a, b, denominator *big.Int) *big.Int {
+	product := new(big.Int).Mul(a, b)
+	return product.Div(product, denominator)
+}
+
+// mulDivRoundingUp calculates ceil(a*b/denominator) with full precision, like FullMath.mulDivRoundingUp
+func mulDivRoundingUp(a, b, denominator *big.Int) *big.Int {
+	product := new(big.Int).Mul(a, b)
+	quotient, remainder := new(big.Int).QuoRem(product, denominator, new(big.Int))
+	if remainder.Sign() > 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+// divRoundingUp calculates ceil(x/y), like UnsafeMath.divRoundingUp
+func divRoundingUp(x, y *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(x, y, new(big.Int))
+	if remainder.Sign() > 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
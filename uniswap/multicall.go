@@ -1,7 +1,6 @@
 package uniswap
 
 import (
-	"math/big"
 	"strings"
 
 	"github.com/ackermanx/ethclient"
@@ -14,6 +13,9 @@ import (
 const (
 	MultiCallAddr    = "0x5ba1e12693dc8f9c48aad8770482f4739beed696"
 	MultiFragmentAbi = `[{"inputs":[{"internalType":"bool","name":"requireSuccess","type":"bool"},{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall2.Call[]","name":"calls","type":"tuple[]"}],"name":"tryAggregate","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall2.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"bool","name":"requireSuccess","type":"bool"},{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall2.Call[]","name":"calls","type":"tuple[]"}],"name":"tryBlockAndAggregate","outputs":[{"internalType":"uint256","name":"blockNumber","type":"uint256"},{"internalType":"bytes32","name":"blockHash","type":"bytes32"},{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall2.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"nonpayable","type":"function"}]`
+
+	// multiCallBatchSize limit calls in one multicall request to stay under node gas limits of eth_call
+	multiCallBatchSize = 500
 )
 
 type Multicall2Call struct {
@@ -21,9 +23,11 @@ type Multicall2Call struct {
 	CallData []byte
 }
 
+// Multicall2Result is the result of one call of tryAggregate, ReturnData is the raw abi encoded output of the
+// call, or the revert data when Success is false. It was a *big.Int before, which could not hold most outputs.
 type Multicall2Result struct {
 	Success    bool
-	ReturnData *big.Int
+	ReturnData []byte
 }
 
 func MultiCall(client *ethclient.Client, methodName string, opts *bind.CallOpts, multiCallParam []Multicall2Call) (out []interface{}, err error) {
@@ -42,3 +46,31 @@ func MultiCall(client *ethclient.Client, methodName string, opts *bind.CallOpts,
 	}
 	return
 }
+
+// TryAggregate call multicall2 tryAggregate in batches, results are in the same order as calls.
+// Failed calls are returned with Success false unless requireSuccess is true.
+func TryAggregate(client *ethclient.Client, opts *bind.CallOpts, requireSuccess bool, calls []Multicall2Call) (results []Multicall2Result, err error) {
+	parsedAbi, err := abi.JSON(strings.NewReader(MultiFragmentAbi))
+	if err != nil {
+		err = errors.Wrap(err, "parsed multi call abi")
+		return
+	}
+	boundedContract := bind.NewBoundContract(common.HexToAddress(MultiCallAddr), parsedAbi, client, client, client)
+
+	results = make([]Multicall2Result, 0, len(calls))
+	for start := 0; start < len(calls); start += multiCallBatchSize {
+		end := start + multiCallBatchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		out := make([]interface{}, 0)
+		err = boundedContract.Call(opts, &out, "tryAggregate", requireSuccess, calls[start:end])
+		if err != nil {
+			err = errors.Wrap(err, "call multi call")
+			return
+		}
+		batch := *abi.ConvertType(out[0], new([]Multicall2Result)).(*[]Multicall2Result)
+		results = append(results, batch...)
+	}
+	return
+}
@@ -0,0 +1,204 @@
+package uniswap
+
+import (
+	"math/big"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// BpsDenominator is the denominator of fees and slippage expressed in basis points
+	BpsDenominator = 10000
+	// FeeBpsV2 is the uniswap v2 swap fee 0.3% in basis points
+	FeeBpsV2 = 30
+	// FeeBpsPancakeV2 is the pancakeswap v2 swap fee 0.25% in basis points
+	FeeBpsPancakeV2 = 25
+)
+
+var (
+	ErrInsufficientInputAmount  = errors.New("insufficient input amount")
+	ErrInsufficientOutputAmount = errors.New("insufficient output amount")
+	ErrInsufficientLiquidity    = errors.New("insufficient liquidity")
+	ErrBpsOutOfRange            = errors.New("basis points out of range [0, 10000)")
+)
+
+// PairV2 is the state of uniswapV2 like pair
+type PairV2 struct {
+	Address            common.Address
+	Token0             common.Address
+	Token1             common.Address
+	Reserve0           *big.Int
+	Reserve1           *big.Int
+	BlockTimestampLast uint32
+	KLast              *big.Int
+}
+
+// LoadPairV2 read uniswapV2 like pair tokens, reserves and kLast through multicall
+func LoadPairV2(client *ethclient.Client, pair common.Address, opts *bind.CallOpts) (p *PairV2, err error) {
+	methods := []string{"getReserves", "token0", "token1", "kLast"}
+	calls := make([]Multicall2Call, 0, len(methods))
+	for _, method := range methods {
+		var callData []byte
+		callData, err = pairV2Abi.Pack(method)
+		if err != nil {
+			err = errors.Wrapf(err, "pack %s", method)
+			return
+		}
+		calls = append(calls, Multicall2Call{Target: pair, CallData: callData})
+	}
+	results, err := TryAggregate(client, opts, false, calls)
+	if err != nil {
+		return
+	}
+
+	outs := make([][]interface{}, len(methods))
+	for i, method := range methods {
+		// kLast is not available on every fork, treat it as zero
+		if method == "kLast" && !results[i].Success {
+			outs[i] = []interface{}{new(big.Int)}
+			continue
+		}
+		if !results[i].Success {
+			err = errors.Errorf("call %s of pair %s failed", method, pair)
+			return
+		}
+		outs[i], err = pairV2Abi.Unpack(method, results[i].ReturnData)
+		if err != nil {
+			err = errors.Wrapf(err, "unpack %s", method)
+			return
+		}
+	}
+
+	p = &PairV2{
+		Address:            pair,
+		Reserve0:           outs[0][0].(*big.Int),
+		Reserve1:           outs[0][1].(*big.Int),
+		BlockTimestampLast: outs[0][2].(uint32),
+		Token0:             outs[1][0].(common.Address),
+		Token1:             outs[2][0].(common.Address),
+		KLast:              outs[3][0].(*big.Int),
+	}
+	return
+}
+
+// Reserves returns reserves of the pair ordered by the swap direction from tokenIn
+func (p *PairV2) Reserves(tokenIn common.Address) (reserveIn, reserveOut *big.Int, err error) {
+	switch tokenIn {
+	case p.Token0:
+		return p.Reserve0, p.Reserve1, nil
+	case p.Token1:
+		return p.Reserve1, p.Reserve0, nil
+	}
+	err = errors.Errorf("token %s not in pair %s", tokenIn, p.Address)
+	return
+}
+
+// GetAmountOutV2 calculate the maximum output amount of the other asset given an input amount,
+// same as UniswapV2Library.getAmountOut with fee in basis points
+func GetAmountOutV2(amountIn, reserveIn, reserveOut *big.Int, feeBps int) (amountOut *big.Int, err error) {
+	if err = checkBps("fee", feeBps); err != nil {
+		return
+	}
+	if amountIn.Sign() <= 0 {
+		err = ErrInsufficientInputAmount
+		return
+	}
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		err = ErrInsufficientLiquidity
+		return
+	}
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(int64(BpsDenominator-feeBps)))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Mul(reserveIn, big.NewInt(BpsDenominator))
+	denominator.Add(denominator, amountInWithFee)
+	return numerator.Div(numerator, denominator), nil
+}
+
+// GetAmountInV2 calculate the required input amount of the other asset given an output amount,
+// same as UniswapV2Library.getAmountIn with fee in basis points
+func GetAmountInV2(amountOut, reserveIn, reserveOut *big.Int, feeBps int) (amountIn *big.Int, err error) {
+	if err = checkBps("fee", feeBps); err != nil {
+		return
+	}
+	if amountOut.Sign() <= 0 {
+		err = ErrInsufficientOutputAmount
+		return
+	}
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 || amountOut.Cmp(reserveOut) >= 0 {
+		err = ErrInsufficientLiquidity
+		return
+	}
+	numerator := new(big.Int).Mul(reserveIn, amountOut)
+	numerator.Mul(numerator, big.NewInt(BpsDenominator))
+	denominator := new(big.Int).Sub(reserveOut, amountOut)
+	denominator.Mul(denominator, big.NewInt(int64(BpsDenominator-feeBps)))
+	amountIn = numerator.Div(numerator, denominator)
+	return amountIn.Add(amountIn, big.NewInt(1)), nil
+}
+
+// GetAmountsOutV2 calculate output amounts of every hop along path, pairs[i] is the pair of path[i] and path[i+1]
+func GetAmountsOutV2(amountIn *big.Int, path []common.Address, pairs []*PairV2, feeBps int) (amounts []*big.Int, err error) {
+	if len(path) < 2 || len(pairs) != len(path)-1 {
+		err = errors.New("path/pair lengths do not match")
+		return
+	}
+	amounts = make([]*big.Int, len(path))
+	amounts[0] = amountIn
+	for i, pair := range pairs {
+		var reserveIn, reserveOut *big.Int
+		reserveIn, reserveOut, err = pair.Reserves(path[i])
+		if err != nil {
+			return
+		}
+		amounts[i+1], err = GetAmountOutV2(amounts[i], reserveIn, reserveOut, feeBps)
+		if err != nil {
+			err = errors.WithMessagef(err, "hop %d", i)
+			return
+		}
+	}
+	return
+}
+
+// GetAmountsInV2 calculate input amounts of every hop along path, pairs[i] is the pair of path[i] and path[i+1]
+func GetAmountsInV2(amountOut *big.Int, path []common.Address, pairs []*PairV2, feeBps int) (amounts []*big.Int, err error) {
+	if len(path) < 2 || len(pairs) != len(path)-1 {
+		err = errors.New("path/pair lengths do not match")
+		return
+	}
+	amounts = make([]*big.Int, len(path))
+	amounts[len(amounts)-1] = amountOut
+	for i := len(pairs) - 1; i >= 0; i-- {
+		var reserveIn, reserveOut *big.Int
+		reserveIn, reserveOut, err = pairs[i].Reserves(path[i])
+		if err != nil {
+			return
+		}
+		amounts[i], err = GetAmountInV2(amounts[i+1], reserveIn, reserveOut, feeBps)
+		if err != nil {
+			err = errors.WithMessagef(err, "hop %d", i)
+			return
+		}
+	}
+	return
+}
+
+// PriceImpactV2 calculate the price impact of swapping amountIn for amountOut against the reserves mid price,
+// 0.01 means 1%. The swap fee is included in the impact.
+func PriceImpactV2(amountIn, amountOut, reserveIn, reserveOut *big.Int) (impact decimal.Decimal, err error) {
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		err = ErrInsufficientLiquidity
+		return
+	}
+	if amountIn.Sign() <= 0 {
+		err = ErrInsufficientInputAmount
+		return
+	}
+	// quoted output at the mid price: amountIn * reserveOut / reserveIn
+	quoted := decimal.NewFromBigInt(amountIn, 0).Mul(decimal.NewFromBigInt(reserveOut, 0)).Div(decimal.NewFromBigInt(reserveIn, 0))
+	impact = quoted.Sub(decimal.NewFromBigInt(amountOut, 0)).Div(quoted)
+	return
+}
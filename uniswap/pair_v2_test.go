@@ -0,0 +1,99 @@
+package uniswap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAmountOutV2(t *testing.T) {
+	amountIn, _ := new(big.Int).SetString("1000000000000000000", 10)
+	reserveIn, _ := new(big.Int).SetString("5000000000000000000", 10)
+	reserveOut, _ := new(big.Int).SetString("10000000000000000000", 10)
+
+	amountOut, err := GetAmountOutV2(amountIn, reserveIn, reserveOut, FeeBpsV2)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "1662497915624478906", amountOut.String())
+
+	pancakeOut, _ := GetAmountOutV2(amountIn, reserveIn, reserveOut, FeeBpsPancakeV2)
+	assert.Equal(t, "1663192997082117548", pancakeOut.String())
+
+	required, err := GetAmountInV2(amountOut, reserveIn, reserveOut, FeeBpsV2)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, amountIn.String(), required.String())
+
+	_, err = GetAmountInV2(reserveOut, reserveIn, reserveOut, FeeBpsV2)
+	assert.ErrorIs(t, err, ErrInsufficientLiquidity)
+	_, err = GetAmountOutV2(big.NewInt(0), reserveIn, reserveOut, FeeBpsV2)
+	assert.ErrorIs(t, err, ErrInsufficientInputAmount)
+
+	for _, feeBps := range []int{-1, BpsDenominator, BpsDenominator + 1} {
+		_, err = GetAmountOutV2(amountIn, reserveIn, reserveOut, feeBps)
+		assert.ErrorIs(t, err, ErrBpsOutOfRange)
+		_, err = GetAmountInV2(amountOut, reserveIn, reserveOut, feeBps)
+		assert.ErrorIs(t, err, ErrBpsOutOfRange)
+	}
+}
+
+func TestGetAmountsOutV2(t *testing.T) {
+	weth := common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	dai := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	usdc := common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	reserve5, _ := new(big.Int).SetString("5000000000000000000", 10)
+	reserve10, _ := new(big.Int).SetString("10000000000000000000", 10)
+	pairs := []*PairV2{
+		{Token0: dai, Token1: weth, Reserve0: reserve10, Reserve1: reserve5},
+		{Token0: usdc, Token1: dai, Reserve0: reserve10, Reserve1: reserve10},
+	}
+	path := []common.Address{weth, dai, usdc}
+	amountIn, _ := new(big.Int).SetString("1000000000000000000", 10)
+
+	amounts, err := GetAmountsOutV2(amountIn, path, pairs, FeeBpsV2)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 3, len(amounts))
+	assert.Equal(t, "1662497915624478906", amounts[1].String())
+	second, _ := GetAmountOutV2(amounts[1], reserve10, reserve10, FeeBpsV2)
+	assert.Equal(t, second.String(), amounts[2].String())
+
+	amountsIn, err := GetAmountsInV2(amounts[2], path, pairs, FeeBpsV2)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.LessOrEqual(t, amountsIn[0].Cmp(amountIn), 0)
+
+	_, err = GetAmountsOutV2(amountIn, []common.Address{usdc, weth}, pairs[:1], FeeBpsV2)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestPriceImpactV2(t *testing.T) {
+	reserve, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	amountIn, _ := new(big.Int).SetString("10000000000000000000", 10)
+	amountOut, _ := GetAmountOutV2(amountIn, reserve, reserve, FeeBpsV2)
+
+	impact, err := PriceImpactV2(amountIn, amountOut, reserve, reserve)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	// 0.3% fee and ~0.99% price movement
+	assert.Equal(t, "0.0128", impact.StringFixed(4))
+
+	minOut, err := MinimumAmountOut(big.NewInt(10000), 50)
+	assert.NoError(t, err)
+	assert.Equal(t, "9950", minOut.String())
+	maxIn, err := MaximumAmountIn(big.NewInt(10001), 50)
+	assert.NoError(t, err)
+	assert.Equal(t, "10052", maxIn.String())
+
+	_, err = MinimumAmountOut(big.NewInt(10000), -1)
+	assert.ErrorIs(t, err, ErrBpsOutOfRange)
+	_, err = MaximumAmountIn(big.NewInt(10000), BpsDenominator)
+	assert.ErrorIs(t, err, ErrBpsOutOfRange)
+}
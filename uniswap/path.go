@@ -14,10 +14,19 @@ const (
 	DataSize = Offset + AddrSize
 )
 
+var (
+	// FeeTiersV3 are the fee tiers enabled on uniswap v3 factory
+	FeeTiersV3 = []int{100, 500, 3000, 10000}
+
+	ErrInvalidPathLength  = errors.New("invalid path length")
+	ErrFeeTierNotEnabled  = errors.New("fee tier not enabled")
+	ErrPathLengthMismatch = errors.New("path/fee lengths do not match")
+)
+
 // EncodePath encode path to bytes
 func EncodePath(path []common.Address, fees []int) (encoded []byte, err error) {
 	if len(path) != len(fees)+1 {
-		err = errors.New("path/fee lengths do not match")
+		err = ErrPathLengthMismatch
 		return
 	}
 	encoded = make([]byte, 0, len(fees)*Offset+AddrSize)
@@ -30,3 +39,83 @@ func EncodePath(path []common.Address, fees []int) (encoded []byte, err error) {
 	encoded = append(encoded, path[len(path)-1].Bytes()...)
 	return
 }
+
+// EncodePathReversed encode path from the last token to the first one,
+// exactOutput swaps of the router take the path in reversed order
+func EncodePathReversed(path []common.Address, fees []int) (encoded []byte, err error) {
+	if len(path) != len(fees)+1 {
+		err = ErrPathLengthMismatch
+		return
+	}
+	reversedPath := make([]common.Address, len(path))
+	for i, token := range path {
+		reversedPath[len(path)-1-i] = token
+	}
+	reversedFees := make([]int, len(fees))
+	for i, fee := range fees {
+		reversedFees[len(fees)-1-i] = fee
+	}
+	return EncodePath(reversedPath, reversedFees)
+}
+
+// DecodePath decode encoded path to tokens and fees
+func DecodePath(encoded []byte) (path []common.Address, fees []int, err error) {
+	if len(encoded) < DataSize || (len(encoded)-AddrSize)%Offset != 0 {
+		err = errors.Wrapf(ErrInvalidPathLength, "length %d", len(encoded))
+		return
+	}
+	hops := (len(encoded) - AddrSize) / Offset
+	path = make([]common.Address, 0, hops+1)
+	fees = make([]int, 0, hops)
+	for i := 0; i < hops; i++ {
+		start := i * Offset
+		path = append(path, common.BytesToAddress(encoded[start:start+AddrSize]))
+		fees = append(fees, int(new(big.Int).SetBytes(encoded[start+AddrSize:start+Offset]).Int64()))
+	}
+	path = append(path, common.BytesToAddress(encoded[hops*Offset:]))
+	return
+}
+
+// ValidatePath check encoded path length and that every fee is in enabledFees,
+// FeeTiersV3 is used if enabledFees is empty
+func ValidatePath(encoded []byte, enabledFees []int) error {
+	_, fees, err := DecodePath(encoded)
+	if err != nil {
+		return err
+	}
+	if len(enabledFees) == 0 {
+		enabledFees = FeeTiersV3
+	}
+	for i, fee := range fees {
+		if !containsFee(enabledFees, fee) {
+			return errors.Wrapf(ErrFeeTierNotEnabled, "hop %d fee %d", i, fee)
+		}
+	}
+	return nil
+}
+
+// PathPoolAddresses calculate uniswapV3 pool address of every hop in encoded path
+func PathPoolAddresses(encoded []byte) (pools []common.Address, err error) {
+	path, fees, err := DecodePath(encoded)
+	if err != nil {
+		return
+	}
+	pools = make([]common.Address, len(fees))
+	for i, fee := range fees {
+		pools[i], err = CalculatePoolAddressV3(path[i].String(), path[i+1].String(), big.NewInt(int64(fee)))
+		if err != nil {
+			err = errors.WithMessagef(err, "hop %d", i)
+			return
+		}
+	}
+	return
+}
+
+func containsFee(fees []int, fee int) bool {
+	for _, f := range fees {
+		if f == fee {
+			return true
+		}
+	}
+	return false
+}
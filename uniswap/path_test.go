@@ -19,3 +19,53 @@ func TestEncodePath(t *testing.T) {
 	encodedPathHex := hex.EncodeToString(encoded)
 	assert.Equal(t, "c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2000bb86b175474e89094c44da98b954eedeac495271d0f", encodedPathHex)
 }
+
+func TestDecodePath(t *testing.T) {
+	weth := common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	dai := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	usdc := common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	encoded, err := EncodePath([]common.Address{weth, dai, usdc}, []int{3000, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, fees, err := DecodePath(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []common.Address{weth, dai, usdc}, path)
+	assert.Equal(t, []int{3000, 100}, fees)
+
+	_, _, err = DecodePath(encoded[:len(encoded)-1])
+	assert.ErrorIs(t, err, ErrInvalidPathLength)
+
+	reversed, err := EncodePathReversed([]common.Address{weth, dai, usdc}, []int{3000, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, fees, _ = DecodePath(reversed)
+	assert.Equal(t, []common.Address{usdc, dai, weth}, path)
+	assert.Equal(t, []int{100, 3000}, fees)
+}
+
+func TestValidatePath(t *testing.T) {
+	weth := common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	dai := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	encoded, _ := EncodePath([]common.Address{weth, dai}, []int{3000})
+	assert.Equal(t, nil, ValidatePath(encoded, nil))
+
+	encoded, _ = EncodePath([]common.Address{weth, dai}, []int{2500})
+	assert.ErrorIs(t, ValidatePath(encoded, nil), ErrFeeTierNotEnabled)
+	assert.Equal(t, nil, ValidatePath(encoded, []int{2500}))
+}
+
+func TestPathPoolAddresses(t *testing.T) {
+	weth := common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	dai := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	encoded, _ := EncodePath([]common.Address{dai, weth}, []int{3000})
+	pools, err := PathPoolAddresses(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []common.Address{common.HexToAddress("0xC2e9F25Be6257c210d7Adf0D4Cd6E3E881ba25f8")}, pools)
+}
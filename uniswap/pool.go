@@ -0,0 +1,247 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrPoolNotLoaded = errors.New("pool state not loaded")
+	ErrLogRemoved    = errors.New("log removed by chain reorganization")
+
+	SwapEventID = poolV3Abi.Events["Swap"].ID
+	MintEventID = poolV3Abi.Events["Mint"].ID
+	BurnEventID = poolV3Abi.Events["Burn"].ID
+)
+
+// Pool keeps uniswap v3 pool state in memory. The state is loaded through multicall and
+// kept current by applying Swap, Mint and Burn logs of the pool.
+//
+// Fee growth is not derivable from the logs, FeeGrowthGlobal0X128 and FeeGrowthGlobal1X128
+// are only refreshed by Load.
+type Pool struct {
+	client  *ethclient.Client
+	Address common.Address
+	// WordRange is the number of tickBitmap words loaded on each side of the current tick
+	WordRange int
+
+	mu       sync.RWMutex
+	state    *PoolState
+	block    uint64
+	logIndex uint
+	loaded   bool
+}
+
+// NewPool creates a pool tracker of uniswap v3 pool address, call Load or Watch to fill the state
+func NewPool(client *ethclient.Client, address common.Address, wordRange int) *Pool {
+	return &Pool{client: client, Address: address, WordRange: wordRange}
+}
+
+// Load read the pool state at the latest block, logs up to that block are ignored afterwards
+func (p *Pool) Load(ctx context.Context) error {
+	blockNumber, err := p.client.BlockNumber(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "get block number")
+	}
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(blockNumber)}
+	state, err := LoadPoolState(p.client, p.Address, opts, p.WordRange)
+	if err != nil {
+		return errors.WithMessagef(err, "load pool %s", p.Address)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state = state
+	p.block = blockNumber
+	p.logIndex = ^uint(0)
+	p.loaded = true
+	return nil
+}
+
+// State returns a snapshot of the pool state which can be passed to SimulateSwap
+func (p *Pool) State() (*PoolState, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if !p.loaded {
+		return nil, ErrPoolNotLoaded
+	}
+	// big.Int values are replaced instead of modified in place, copying the tick slice is enough
+	state := *p.state
+	state.Ticks = append([]Tick(nil), p.state.Ticks...)
+	return &state, nil
+}
+
+// BlockNumber returns the block of the last applied log or of the loaded state
+func (p *Pool) BlockNumber() uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.block
+}
+
+// FilterQuery returns the query of the Swap, Mint and Burn logs of the pool
+func (p *Pool) FilterQuery() ethereum.FilterQuery {
+	return ethereum.FilterQuery{
+		Addresses: []common.Address{p.Address},
+		Topics:    [][]common.Hash{{SwapEventID, MintEventID, BurnEventID}},
+	}
+}
+
+// ApplyLog update the pool state from a Swap, Mint or Burn log. Logs already covered by
+// the state are ignored and removed logs return ErrLogRemoved, the pool must be loaded again then.
+func (p *Pool) ApplyLog(log types.Log) error {
+	if log.Address != p.Address {
+		return errors.Errorf("log of %s applied to pool %s", log.Address, p.Address)
+	}
+	if len(log.Topics) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.loaded {
+		return ErrPoolNotLoaded
+	}
+	if log.Removed {
+		return errors.Wrapf(ErrLogRemoved, "block %d log %d", log.BlockNumber, log.Index)
+	}
+	if log.BlockNumber < p.block || (log.BlockNumber == p.block && (p.logIndex == ^uint(0) || log.Index <= p.logIndex)) {
+		return nil
+	}
+
+	var err error
+	switch log.Topics[0] {
+	case SwapEventID:
+		err = p.applySwap(log)
+	case MintEventID:
+		err = p.applyModifyPosition(log, "Mint", false)
+	case BurnEventID:
+		err = p.applyModifyPosition(log, "Burn", true)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	p.block, p.logIndex = log.BlockNumber, log.Index
+	return nil
+}
+
+func (p *Pool) applySwap(log types.Log) error {
+	values, err := poolV3Abi.Unpack("Swap", log.Data)
+	if err != nil {
+		return errors.Wrap(err, "unpack swap log")
+	}
+	p.state.SqrtPriceX96 = values[2].(*big.Int)
+	p.state.Liquidity = values[3].(*big.Int)
+	p.state.Tick = int(values[4].(*big.Int).Int64())
+	return nil
+}
+
+func (p *Pool) applyModifyPosition(log types.Log, event string, burn bool) error {
+	if len(log.Topics) != 4 {
+		return errors.Errorf("%s log has %d topics", event, len(log.Topics))
+	}
+	values, err := poolV3Abi.Unpack(event, log.Data)
+	if err != nil {
+		return errors.Wrapf(err, "unpack %s log", event)
+	}
+	// Mint data starts with the sender, Burn data starts with the amount
+	amount := values[0]
+	if !burn {
+		amount = values[1]
+	}
+	liquidityDelta := new(big.Int).Set(amount.(*big.Int))
+	if liquidityDelta.Sign() == 0 {
+		return nil
+	}
+	if burn {
+		liquidityDelta.Neg(liquidityDelta)
+	}
+
+	tickLower, tickUpper := topicToInt(log.Topics[2]), topicToInt(log.Topics[3])
+	p.state.Ticks = updateTick(p.state.Ticks, tickLower, liquidityDelta, false)
+	p.state.Ticks = updateTick(p.state.Ticks, tickUpper, liquidityDelta, true)
+	if tickLower <= p.state.Tick && p.state.Tick < tickUpper {
+		p.state.Liquidity = new(big.Int).Add(p.state.Liquidity, liquidityDelta)
+	}
+	return nil
+}
+
+// updateTick apply liquidityDelta to the tick like Tick.update, ticks without liquidity are removed
+func updateTick(ticks []Tick, index int, liquidityDelta *big.Int, upper bool) []Tick {
+	netDelta := liquidityDelta
+	if upper {
+		netDelta = new(big.Int).Neg(liquidityDelta)
+	}
+
+	i := sort.Search(len(ticks), func(i int) bool { return ticks[i].Index >= index })
+	if i == len(ticks) || ticks[i].Index != index {
+		ticks = append(ticks, Tick{})
+		copy(ticks[i+1:], ticks[i:])
+		ticks[i] = Tick{Index: index, LiquidityNet: new(big.Int), LiquidityGross: new(big.Int)}
+	}
+
+	tick := ticks[i]
+	gross := tick.LiquidityGross
+	if gross == nil {
+		gross = new(big.Int)
+	}
+	net := tick.LiquidityNet
+	if net == nil {
+		net = new(big.Int)
+	}
+	tick.LiquidityGross = new(big.Int).Add(gross, liquidityDelta)
+	tick.LiquidityNet = new(big.Int).Add(net, netDelta)
+	if tick.LiquidityGross.Sign() <= 0 {
+		return append(ticks[:i], ticks[i+1:]...)
+	}
+	ticks[i] = tick
+	return ticks
+}
+
+// Watch subscribe the pool logs, load the state and apply logs until ctx is done or the subscription fails.
+// The pool is loaded again after a chain reorganization.
+func (p *Pool) Watch(ctx context.Context) error {
+	logs := make(chan types.Log, 128)
+	sub, err := p.client.SubscribeFilterLogs(ctx, p.FilterQuery(), logs)
+	if err != nil {
+		return errors.WithMessage(err, "subscribe pool logs")
+	}
+	defer sub.Unsubscribe()
+
+	// logs arriving while loading are buffered by the channel and skipped if already covered
+	if err = p.Load(ctx); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err = <-sub.Err():
+			return errors.WithMessage(err, "pool logs subscription")
+		case log := <-logs:
+			err = p.ApplyLog(log)
+			if errors.Is(err, ErrLogRemoved) {
+				err = p.Load(ctx)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// topicToInt decode a signed integer of at most 64 bits from an indexed topic
+func topicToInt(topic common.Hash) int {
+	return int(int64(binary.BigEndian.Uint64(topic[24:])))
+}
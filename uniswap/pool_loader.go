@@ -0,0 +1,159 @@
+package uniswap
+
+import (
+	"math/big"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// LoadPoolState load uniswap v3 pool state through multicall for offline swap simulation.
+// Pin opts.BlockNumber to read a consistent state, the state is read in several requests.
+// Initialized ticks are read from the tickBitmap words within wordRange words around the current tick,
+// one word covers 256 * tickSpacing ticks.
+func LoadPoolState(client *ethclient.Client, pool common.Address, opts *bind.CallOpts, wordRange int) (state *PoolState, err error) {
+	calls := make([]Multicall2Call, 0, 6)
+	for _, method := range []string{"slot0", "liquidity", "fee", "tickSpacing", "feeGrowthGlobal0X128", "feeGrowthGlobal1X128"} {
+		var callData []byte
+		callData, err = poolV3Abi.Pack(method)
+		if err != nil {
+			err = errors.Wrapf(err, "pack %s", method)
+			return
+		}
+		calls = append(calls, Multicall2Call{Target: pool, CallData: callData})
+	}
+	results, err := TryAggregate(client, opts, true, calls)
+	if err != nil {
+		return
+	}
+
+	slot0, err := poolV3Abi.Unpack("slot0", results[0].ReturnData)
+	if err != nil {
+		err = errors.Wrap(err, "unpack slot0")
+		return
+	}
+	liquidity, err := poolV3Abi.Unpack("liquidity", results[1].ReturnData)
+	if err != nil {
+		err = errors.Wrap(err, "unpack liquidity")
+		return
+	}
+	fee, err := poolV3Abi.Unpack("fee", results[2].ReturnData)
+	if err != nil {
+		err = errors.Wrap(err, "unpack fee")
+		return
+	}
+	tickSpacing, err := poolV3Abi.Unpack("tickSpacing", results[3].ReturnData)
+	if err != nil {
+		err = errors.Wrap(err, "unpack tickSpacing")
+		return
+	}
+	feeGrowthGlobal0X128, err := poolV3Abi.Unpack("feeGrowthGlobal0X128", results[4].ReturnData)
+	if err != nil {
+		err = errors.Wrap(err, "unpack feeGrowthGlobal0X128")
+		return
+	}
+	feeGrowthGlobal1X128, err := poolV3Abi.Unpack("feeGrowthGlobal1X128", results[5].ReturnData)
+	if err != nil {
+		err = errors.Wrap(err, "unpack feeGrowthGlobal1X128")
+		return
+	}
+
+	if err = checkPoolPrice(slot0[0].(*big.Int)); err != nil {
+		err = errors.WithMessagef(err, "pool %s", pool)
+		return
+	}
+
+	state = &PoolState{
+		SqrtPriceX96: slot0[0].(*big.Int),
+		Tick:         int(slot0[1].(*big.Int).Int64()),
+		Liquidity:    liquidity[0].(*big.Int),
+		Fee:          int(fee[0].(*big.Int).Int64()),
+		TickSpacing:  int(tickSpacing[0].(*big.Int).Int64()),
+
+		FeeGrowthGlobal0X128: feeGrowthGlobal0X128[0].(*big.Int),
+		FeeGrowthGlobal1X128: feeGrowthGlobal1X128[0].(*big.Int),
+	}
+	state.Ticks, state.TickLowerBound, state.TickUpperBound, err = loadTicks(client, pool, opts, state.Tick, state.TickSpacing, wordRange)
+	return
+}
+
+// loadTicks load initialized ticks from the tickBitmap words around tick, returns the ticks sorted by index
+// and the tick range covered by the loaded words
+func loadTicks(client *ethclient.Client, pool common.Address, opts *bind.CallOpts, tick, tickSpacing, wordRange int) (ticks []Tick, lowerBound, upperBound int, err error) {
+	minWord := floorDiv(MinTick, tickSpacing) >> 8
+	maxWord := floorDiv(MaxTick, tickSpacing) >> 8
+	currentWord := floorDiv(tick, tickSpacing) >> 8
+	fromWord, toWord := currentWord-wordRange, currentWord+wordRange
+	if fromWord < minWord {
+		fromWord = minWord
+	}
+	if toWord > maxWord {
+		toWord = maxWord
+	}
+
+	calls := make([]Multicall2Call, 0, toWord-fromWord+1)
+	for word := fromWord; word <= toWord; word++ {
+		var callData []byte
+		callData, err = poolV3Abi.Pack("tickBitmap", int16(word))
+		if err != nil {
+			err = errors.Wrap(err, "pack tickBitmap")
+			return
+		}
+		calls = append(calls, Multicall2Call{Target: pool, CallData: callData})
+	}
+	results, err := TryAggregate(client, opts, true, calls)
+	if err != nil {
+		return
+	}
+
+	indexes := make([]int, 0)
+	for i, result := range results {
+		var bitmap []interface{}
+		bitmap, err = poolV3Abi.Unpack("tickBitmap", result.ReturnData)
+		if err != nil {
+			err = errors.Wrap(err, "unpack tickBitmap")
+			return
+		}
+		word := bitmap[0].(*big.Int)
+		for bit := 0; bit < 256; bit++ {
+			if word.Bit(bit) == 1 {
+				indexes = append(indexes, ((fromWord+i)<<8+bit)*tickSpacing)
+			}
+		}
+	}
+
+	calls = make([]Multicall2Call, 0, len(indexes))
+	for _, index := range indexes {
+		var callData []byte
+		callData, err = poolV3Abi.Pack("ticks", big.NewInt(int64(index)))
+		if err != nil {
+			err = errors.Wrap(err, "pack ticks")
+			return
+		}
+		calls = append(calls, Multicall2Call{Target: pool, CallData: callData})
+	}
+	results, err = TryAggregate(client, opts, true, calls)
+	if err != nil {
+		return
+	}
+
+	ticks = make([]Tick, 0, len(indexes))
+	for i, result := range results {
+		var info []interface{}
+		info, err = poolV3Abi.Unpack("ticks", result.ReturnData)
+		if err != nil {
+			err = errors.Wrap(err, "unpack ticks")
+			return
+		}
+		ticks = append(ticks, Tick{Index: indexes[i], LiquidityGross: info[0].(*big.Int), LiquidityNet: info[1].(*big.Int)})
+	}
+
+	lowerBound = (fromWord << 8) * tickSpacing
+	upperBound = (toWord<<8 + 0xff) * tickSpacing
+	if fromWord == minWord && toWord == maxWord {
+		lowerBound, upperBound = 0, 0
+	}
+	return
+}
@@ -0,0 +1,91 @@
+package uniswap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func tickTopic(tick int64) common.Hash {
+	return common.BigToHash(new(big.Int).And(big.NewInt(tick), maxUint256))
+}
+
+func TestPoolApplyLog(t *testing.T) {
+	address := common.HexToAddress("0xC2e9F25Be6257c210d7Adf0D4Cd6E3E881ba25f8")
+	owner := common.HexToHash("0x431beE0E54b49105964E11b9035A198A1D4735AD")
+	pool := NewPool(nil, address, 1)
+	pool.state = testPoolState()
+	pool.block, pool.logIndex, pool.loaded = 10, ^uint(0), true
+	amount := big.NewInt(1000)
+
+	mintData, err := poolV3Abi.Events["Mint"].Inputs.NonIndexed().Pack(common.Address{}, amount, big.NewInt(1), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mint := types.Log{
+		Address:     address,
+		Topics:      []common.Hash{MintEventID, owner, tickTopic(-60), tickTopic(60)},
+		Data:        mintData,
+		BlockNumber: 11,
+		Index:       0,
+	}
+	before, _ := pool.State()
+	if err = pool.ApplyLog(mint); err != nil {
+		t.Fatal(err)
+	}
+	state, _ := pool.State()
+	assert.Equal(t, new(big.Int).Add(before.Liquidity, amount).String(), state.Liquidity.String())
+	assert.Equal(t, []int{-887220, -120, -60, 60, 120, 887220}, tickIndexes(state.Ticks))
+	assert.Equal(t, "-1000", tickLiquidityNet(state.Ticks, 60).String())
+	assert.Equal(t, uint64(11), pool.BlockNumber())
+
+	// the same log is applied only once
+	assert.Equal(t, nil, pool.ApplyLog(mint))
+	state, _ = pool.State()
+	assert.Equal(t, new(big.Int).Add(before.Liquidity, amount).String(), state.Liquidity.String())
+
+	burnData, _ := poolV3Abi.Events["Burn"].Inputs.NonIndexed().Pack(amount, big.NewInt(1), big.NewInt(1))
+	burn := types.Log{
+		Address:     address,
+		Topics:      []common.Hash{BurnEventID, owner, tickTopic(-60), tickTopic(60)},
+		Data:        burnData,
+		BlockNumber: 11,
+		Index:       1,
+	}
+	if err = pool.ApplyLog(burn); err != nil {
+		t.Fatal(err)
+	}
+	state, _ = pool.State()
+	assert.Equal(t, before.Liquidity.String(), state.Liquidity.String())
+	assert.Equal(t, tickIndexes(before.Ticks), tickIndexes(state.Ticks))
+
+	sqrtPrice, _ := GetSqrtRatioAtTick(-100)
+	swapData, _ := poolV3Abi.Events["Swap"].Inputs.NonIndexed().Pack(big.NewInt(1), big.NewInt(-1), sqrtPrice, big.NewInt(5), big.NewInt(-100))
+	swap := types.Log{
+		Address:     address,
+		Topics:      []common.Hash{SwapEventID, owner, owner},
+		Data:        swapData,
+		BlockNumber: 12,
+	}
+	if err = pool.ApplyLog(swap); err != nil {
+		t.Fatal(err)
+	}
+	state, _ = pool.State()
+	assert.Equal(t, -100, state.Tick)
+	assert.Equal(t, sqrtPrice.String(), state.SqrtPriceX96.String())
+	assert.Equal(t, "5", state.Liquidity.String())
+
+	swap.Removed = true
+	assert.ErrorIs(t, pool.ApplyLog(swap), ErrLogRemoved)
+}
+
+func tickIndexes(ticks []Tick) []int {
+	indexes := make([]int, len(ticks))
+	for i, tick := range ticks {
+		indexes[i] = tick.Index
+	}
+	return indexes
+}
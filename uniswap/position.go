@@ -0,0 +1,310 @@
+package uniswap
+
+import (
+	"math/big"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	PositionManagerAddr = "0xC36442b4a4522E871399CD717aBDD847Ab11FE88"
+	PositionManagerAbi  = `[{"inputs":[{"internalType":"uint256","name":"tokenId","type":"uint256"}],"name":"positions","outputs":[{"internalType":"uint96","name":"nonce","type":"uint96"},{"internalType":"address","name":"operator","type":"address"},{"internalType":"address","name":"token0","type":"address"},{"internalType":"address","name":"token1","type":"address"},{"internalType":"uint24","name":"fee","type":"uint24"},{"internalType":"int24","name":"tickLower","type":"int24"},{"internalType":"int24","name":"tickUpper","type":"int24"},{"internalType":"uint128","name":"liquidity","type":"uint128"},{"internalType":"uint256","name":"feeGrowthInside0LastX128","type":"uint256"},{"internalType":"uint256","name":"feeGrowthInside1LastX128","type":"uint256"},{"internalType":"uint128","name":"tokensOwed0","type":"uint128"},{"internalType":"uint128","name":"tokensOwed1","type":"uint128"}],"stateMutability":"view","type":"function"},{"inputs":[{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"uint256","name":"amount0Desired","type":"uint256"},{"internalType":"uint256","name":"amount1Desired","type":"uint256"},{"internalType":"uint256","name":"amount0Min","type":"uint256"},{"internalType":"uint256","name":"amount1Min","type":"uint256"},{"internalType":"uint256","name":"deadline","type":"uint256"}],"internalType":"struct INonfungiblePositionManager.IncreaseLiquidityParams","name":"params","type":"tuple"}],"name":"increaseLiquidity","outputs":[{"internalType":"uint128","name":"liquidity","type":"uint128"},{"internalType":"uint256","name":"amount0","type":"uint256"},{"internalType":"uint256","name":"amount1","type":"uint256"}],"stateMutability":"payable","type":"function"},{"inputs":[{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"uint128","name":"liquidity","type":"uint128"},{"internalType":"uint256","name":"amount0Min","type":"uint256"},{"internalType":"uint256","name":"amount1Min","type":"uint256"},{"internalType":"uint256","name":"deadline","type":"uint256"}],"internalType":"struct INonfungiblePositionManager.DecreaseLiquidityParams","name":"params","type":"tuple"}],"name":"decreaseLiquidity","outputs":[{"internalType":"uint256","name":"amount0","type":"uint256"},{"internalType":"uint256","name":"amount1","type":"uint256"}],"stateMutability":"payable","type":"function"},{"inputs":[{"components":[{"internalType":"uint256","name":"tokenId","type":"uint256"},{"internalType":"address","name":"recipient","type":"address"},{"internalType":"uint128","name":"amount0Max","type":"uint128"},{"internalType":"uint128","name":"amount1Max","type":"uint128"}],"internalType":"struct INonfungiblePositionManager.CollectParams","name":"params","type":"tuple"}],"name":"collect","outputs":[{"internalType":"uint256","name":"amount0","type":"uint256"},{"internalType":"uint256","name":"amount1","type":"uint256"}],"stateMutability":"payable","type":"function"}]`
+)
+
+var (
+	positionManagerAbi = mustParseAbi(PositionManagerAbi)
+	maxUint128         = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+)
+
+// Position is a uniswap v3 liquidity position of NonfungiblePositionManager
+type Position struct {
+	TokenID                  *big.Int
+	Nonce                    *big.Int
+	Operator                 common.Address
+	Token0                   common.Address
+	Token1                   common.Address
+	Fee                      int
+	TickLower                int
+	TickUpper                int
+	Liquidity                *big.Int
+	FeeGrowthInside0LastX128 *big.Int
+	FeeGrowthInside1LastX128 *big.Int
+	TokensOwed0              *big.Int
+	TokensOwed1              *big.Int
+}
+
+// TickFeeGrowth is the fee growth outside of a tick, read from the pool ticks method
+type TickFeeGrowth struct {
+	FeeGrowthOutside0X128 *big.Int
+	FeeGrowthOutside1X128 *big.Int
+}
+
+// PositionValue is the value of a position at the current pool price
+type PositionValue struct {
+	Position     *Position
+	Pool         common.Address
+	SqrtPriceX96 *big.Int
+	Tick         int
+	// Amount0 and Amount1 are the token amounts withdrawn by removing all liquidity
+	Amount0 *big.Int
+	Amount1 *big.Int
+	// Fees0 and Fees1 are the uncollected fees including tokens owed
+	Fees0 *big.Int
+	Fees1 *big.Int
+}
+
+// Amounts calculate the token amounts of the position at the pool price, rounded down like burning liquidity
+func (p *Position) Amounts(sqrtPriceX96 *big.Int, tick int) (amount0, amount1 *big.Int, err error) {
+	sqrtRatioLowerX96, err := GetSqrtRatioAtTick(p.TickLower)
+	if err != nil {
+		return
+	}
+	sqrtRatioUpperX96, err := GetSqrtRatioAtTick(p.TickUpper)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case tick < p.TickLower:
+		amount0 = GetAmount0Delta(sqrtRatioLowerX96, sqrtRatioUpperX96, p.Liquidity, false)
+		amount1 = new(big.Int)
+	case tick < p.TickUpper:
+		amount0 = GetAmount0Delta(sqrtPriceX96, sqrtRatioUpperX96, p.Liquidity, false)
+		amount1 = GetAmount1Delta(sqrtRatioLowerX96, sqrtPriceX96, p.Liquidity, false)
+	default:
+		amount0 = new(big.Int)
+		amount1 = GetAmount1Delta(sqrtRatioLowerX96, sqrtRatioUpperX96, p.Liquidity, false)
+	}
+	return
+}
+
+// UncollectedFees calculate the fees owed to the position, same as the pool does when the position is poked.
+// feeGrowthGlobal0X128 and feeGrowthGlobal1X128 come from the pool state, lower and upper from the position ticks.
+func (p *Position) UncollectedFees(tick int, feeGrowthGlobal0X128, feeGrowthGlobal1X128 *big.Int, lower, upper TickFeeGrowth) (fees0, fees1 *big.Int) {
+	inside0 := feeGrowthInside(tick, p.TickLower, p.TickUpper, feeGrowthGlobal0X128, lower.FeeGrowthOutside0X128, upper.FeeGrowthOutside0X128)
+	inside1 := feeGrowthInside(tick, p.TickLower, p.TickUpper, feeGrowthGlobal1X128, lower.FeeGrowthOutside1X128, upper.FeeGrowthOutside1X128)
+
+	fees0 = mulDiv(subUint256(inside0, p.FeeGrowthInside0LastX128), p.Liquidity, Q128)
+	fees1 = mulDiv(subUint256(inside1, p.FeeGrowthInside1LastX128), p.Liquidity, Q128)
+	fees0.Add(fees0, p.TokensOwed0)
+	fees1.Add(fees1, p.TokensOwed1)
+	return
+}
+
+// feeGrowthInside calculate the fee growth inside of the tick range, same as Tick.getFeeGrowthInside
+func feeGrowthInside(tick, tickLower, tickUpper int, feeGrowthGlobal, lowerOutside, upperOutside *big.Int) *big.Int {
+	below := lowerOutside
+	if tick < tickLower {
+		below = subUint256(feeGrowthGlobal, lowerOutside)
+	}
+	above := upperOutside
+	if tick >= tickUpper {
+		above = subUint256(feeGrowthGlobal, upperOutside)
+	}
+	return subUint256(subUint256(feeGrowthGlobal, below), above)
+}
+
+// subUint256 calculate a-b with uint256 wrapping, fee growth values overflow by design
+func subUint256(a, b *big.Int) *big.Int {
+	diff := new(big.Int).Sub(a, b)
+	return diff.And(diff, maxUint256)
+}
+
+// PositionManager reads positions and builds liquidity transactions of uniswap v3 NonfungiblePositionManager
+type PositionManager struct {
+	client  *ethclient.Client
+	Address common.Address
+	// Dex calculates the pool addresses of positions
+	Dex Dex
+}
+
+// NewPositionManager creates position manager of address, pools of positions are calculated by dex
+func NewPositionManager(client *ethclient.Client, address common.Address, dex Dex) *PositionManager {
+	return &PositionManager{client: client, Address: address, Dex: dex}
+}
+
+// Position read the position of tokenID
+func (m *PositionManager) Position(opts *bind.CallOpts, tokenID *big.Int) (position *Position, err error) {
+	var results = make([]interface{}, 0)
+	err = m.client.Call(m.Address, opts, &results, "positions", PositionManagerAbi, tokenID)
+	if err != nil {
+		err = errors.WithMessagef(err, "call positions of token %s", tokenID)
+		return
+	}
+	return positionFromResults(tokenID, results), nil
+}
+
+func positionFromResults(tokenID *big.Int, results []interface{}) *Position {
+	return &Position{
+		TokenID:                  tokenID,
+		Nonce:                    results[0].(*big.Int),
+		Operator:                 results[1].(common.Address),
+		Token0:                   results[2].(common.Address),
+		Token1:                   results[3].(common.Address),
+		Fee:                      int(results[4].(*big.Int).Int64()),
+		TickLower:                int(results[5].(*big.Int).Int64()),
+		TickUpper:                int(results[6].(*big.Int).Int64()),
+		Liquidity:                results[7].(*big.Int),
+		FeeGrowthInside0LastX128: results[8].(*big.Int),
+		FeeGrowthInside1LastX128: results[9].(*big.Int),
+		TokensOwed0:              results[10].(*big.Int),
+		TokensOwed1:              results[11].(*big.Int),
+	}
+}
+
+// PositionValue read the position of tokenID, then its pool state in one multicall, and calculate the token
+// amounts and uncollected fees at the current price. The pool is only known from the position, so they are
+// read in two requests: pin opts.BlockNumber to read a consistent state.
+func (m *PositionManager) PositionValue(opts *bind.CallOpts, tokenID *big.Int) (value *PositionValue, err error) {
+	position, err := m.Position(opts, tokenID)
+	if err != nil {
+		return
+	}
+	pool, err := m.Dex.PoolAddress(position.Token0, position.Token1, position.Fee)
+	if err != nil {
+		return
+	}
+
+	calls := make([]Multicall2Call, 0, 5)
+	for _, method := range []string{"slot0", "feeGrowthGlobal0X128", "feeGrowthGlobal1X128"} {
+		var callData []byte
+		callData, err = poolV3Abi.Pack(method)
+		if err != nil {
+			err = errors.Wrapf(err, "pack %s", method)
+			return
+		}
+		calls = append(calls, Multicall2Call{Target: pool, CallData: callData})
+	}
+	for _, tick := range []int{position.TickLower, position.TickUpper} {
+		var callData []byte
+		callData, err = poolV3Abi.Pack("ticks", big.NewInt(int64(tick)))
+		if err != nil {
+			err = errors.Wrap(err, "pack ticks")
+			return
+		}
+		calls = append(calls, Multicall2Call{Target: pool, CallData: callData})
+	}
+	results, err := TryAggregate(m.client, opts, true, calls)
+	if err != nil {
+		return
+	}
+
+	outs := make([][]interface{}, len(results))
+	for i, method := range []string{"slot0", "feeGrowthGlobal0X128", "feeGrowthGlobal1X128", "ticks", "ticks"} {
+		outs[i], err = poolV3Abi.Unpack(method, results[i].ReturnData)
+		if err != nil {
+			err = errors.Wrapf(err, "unpack %s", method)
+			return
+		}
+	}
+
+	value = &PositionValue{
+		Position:     position,
+		Pool:         pool,
+		SqrtPriceX96: outs[0][0].(*big.Int),
+		Tick:         int(outs[0][1].(*big.Int).Int64()),
+	}
+	value.Amount0, value.Amount1, err = position.Amounts(value.SqrtPriceX96, value.Tick)
+	if err != nil {
+		return
+	}
+	lower := TickFeeGrowth{FeeGrowthOutside0X128: outs[3][2].(*big.Int), FeeGrowthOutside1X128: outs[3][3].(*big.Int)}
+	upper := TickFeeGrowth{FeeGrowthOutside0X128: outs[4][2].(*big.Int), FeeGrowthOutside1X128: outs[4][3].(*big.Int)}
+	value.Fees0, value.Fees1 = position.UncollectedFees(value.Tick, outs[1][0].(*big.Int), outs[2][0].(*big.Int), lower, upper)
+	return
+}
+
+// IncreaseLiquidityParams is the parameter of NonfungiblePositionManager.increaseLiquidity
+type IncreaseLiquidityParams struct {
+	TokenId        *big.Int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Deadline       *big.Int
+}
+
+// DecreaseLiquidityParams is the parameter of NonfungiblePositionManager.decreaseLiquidity
+type DecreaseLiquidityParams struct {
+	TokenId    *big.Int
+	Liquidity  *big.Int
+	Amount0Min *big.Int
+	Amount1Min *big.Int
+	Deadline   *big.Int
+}
+
+// CollectParams is the parameter of NonfungiblePositionManager.collect
+type CollectParams struct {
+	TokenId    *big.Int
+	Recipient  common.Address
+	Amount0Max *big.Int
+	Amount1Max *big.Int
+}
+
+// IncreaseLiquidity build increaseLiquidity transaction adding up to the desired amounts to the position,
+// the minimum amounts are the desired amounts less params.SlippageBps
+func (m *PositionManager) IncreaseLiquidity(privKey string, opts *bind.TransactOpts, tokenID, amount0Desired, amount1Desired *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amount0Min, err := MinimumAmountOut(amount0Desired, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	amount1Min, err := MinimumAmountOut(amount1Desired, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	return m.client.BuildContractTx(privKey, "increaseLiquidity", PositionManagerAbi, &m.Address, opts, IncreaseLiquidityParams{
+		TokenId:        tokenID,
+		Amount0Desired: amount0Desired,
+		Amount1Desired: amount1Desired,
+		Amount0Min:     amount0Min,
+		Amount1Min:     amount1Min,
+		Deadline:       params.deadline(),
+	})
+}
+
+// DecreaseLiquidity build decreaseLiquidity transaction removing liquidity from the position, the minimum
+// amounts are the expected amounts (see Position.Amounts) less params.SlippageBps. Tokens are credited to
+// the position and must be collected afterwards.
+func (m *PositionManager) DecreaseLiquidity(privKey string, opts *bind.TransactOpts, tokenID, liquidity, amount0Expected, amount1Expected *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amount0Min, err := MinimumAmountOut(amount0Expected, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	amount1Min, err := MinimumAmountOut(amount1Expected, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	return m.client.BuildContractTx(privKey, "decreaseLiquidity", PositionManagerAbi, &m.Address, opts, DecreaseLiquidityParams{
+		TokenId:    tokenID,
+		Liquidity:  liquidity,
+		Amount0Min: amount0Min,
+		Amount1Min: amount1Min,
+		Deadline:   params.deadline(),
+	})
+}
+
+// Collect build collect transaction sending all owed tokens of the position to params.Recipient
+func (m *PositionManager) Collect(privKey string, opts *bind.TransactOpts, tokenID *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	return m.client.BuildContractTx(privKey, "collect", PositionManagerAbi, &m.Address, opts, CollectParams{
+		TokenId:    tokenID,
+		Recipient:  params.recipient(opts.From),
+		Amount0Max: maxUint128,
+		Amount1Max: maxUint128,
+	})
+}
@@ -0,0 +1,78 @@
+package uniswap
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionManagerSelectors(t *testing.T) {
+	assert.Equal(t, "99fbab88", hex.EncodeToString(positionManagerAbi.Methods["positions"].ID))
+	assert.Equal(t, "219f5d17", hex.EncodeToString(positionManagerAbi.Methods["increaseLiquidity"].ID))
+	assert.Equal(t, "0c49ccbe", hex.EncodeToString(positionManagerAbi.Methods["decreaseLiquidity"].ID))
+	assert.Equal(t, "fc6f7865", hex.EncodeToString(positionManagerAbi.Methods["collect"].ID))
+}
+
+func TestPositionAmounts(t *testing.T) {
+	liquidity, _ := new(big.Int).SetString("1000000000000000000", 10)
+	position := &Position{TickLower: -60, TickUpper: 60, Liquidity: liquidity}
+
+	// in range at price 1 the position holds about the same amount of both tokens
+	amount0, amount1, err := position.Amounts(Q96, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := new(big.Int).Sub(amount0, amount1)
+	assert.Equal(t, true, amount0.Sign() > 0 && diff.CmpAbs(big.NewInt(2)) <= 0)
+
+	sqrtPriceX96, _ := GetSqrtRatioAtTick(-120)
+	amount0, amount1, err = position.Amounts(sqrtPriceX96, -120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetAmount0Delta(mustSqrtRatio(-60), mustSqrtRatio(60), liquidity, false), amount0)
+	assert.Equal(t, 0, amount1.Sign())
+
+	sqrtPriceX96, _ = GetSqrtRatioAtTick(120)
+	amount0, amount1, err = position.Amounts(sqrtPriceX96, 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, amount0.Sign())
+	assert.Equal(t, GetAmount1Delta(mustSqrtRatio(-60), mustSqrtRatio(60), liquidity, false), amount1)
+}
+
+func TestPositionUncollectedFees(t *testing.T) {
+	x128 := func(v int64) *big.Int { return new(big.Int).Mul(big.NewInt(v), Q128) }
+	position := &Position{
+		TickLower:                -60,
+		TickUpper:                60,
+		Liquidity:                big.NewInt(1000),
+		FeeGrowthInside0LastX128: x128(60),
+		// fee growth inside wraps around uint256
+		FeeGrowthInside1LastX128: subUint256(new(big.Int), x128(30)),
+		TokensOwed0:              big.NewInt(7),
+		TokensOwed1:              big.NewInt(0),
+	}
+	lower := TickFeeGrowth{FeeGrowthOutside0X128: x128(10), FeeGrowthOutside1X128: x128(10)}
+	upper := TickFeeGrowth{FeeGrowthOutside0X128: x128(20), FeeGrowthOutside1X128: x128(20)}
+
+	fees0, fees1 := position.UncollectedFees(0, x128(100), x128(5), lower, upper)
+	assert.Equal(t, "10007", fees0.String())
+	assert.Equal(t, "5000", fees1.String())
+
+	// below the range the fee growth inside is lower outside - upper outside
+	position.FeeGrowthInside0LastX128 = subUint256(new(big.Int), x128(15))
+	fees0, _ = position.UncollectedFees(-100, x128(100), x128(5), lower, upper)
+	assert.Equal(t, "5007", fees0.String())
+}
+
+func mustSqrtRatio(tick int) *big.Int {
+	sqrtRatioX96, err := GetSqrtRatioAtTick(tick)
+	if err != nil {
+		panic(err)
+	}
+	return sqrtRatioX96
+}
@@ -0,0 +1,232 @@
+package uniswap
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	VersionV2 = "v2"
+	VersionV3 = "v3"
+
+	DexUniswapV2     = "uniswap-v2"
+	DexUniswapV3     = "uniswap-v3"
+	DexSushiswap     = "sushiswap"
+	DexPancakeSwapV2 = "pancakeswap-v2"
+	DexPancakeSwapV3 = "pancakeswap-v3"
+	DexQuickSwap     = "quickswap"
+	DexTraderJoe     = "traderjoe"
+
+	ChainIDEthereum  = 1
+	ChainIDOptimism  = 10
+	ChainIDBSC       = 56
+	ChainIDPolygon   = 137
+	ChainIDBase      = 8453
+	ChainIDArbitrum  = 42161
+	ChainIDAvalanche = 43114
+)
+
+var (
+	ErrDexNotFound = errors.New("dex not found")
+
+	// DefaultRegistry contains the known DEX deployments
+	DefaultRegistry = NewRegistry()
+)
+
+// Dex is a uniswap like DEX deployment on a chain
+type Dex struct {
+	Name    string         `json:"name"`
+	ChainID uint64         `json:"chainId"`
+	Version string         `json:"version"`
+	Factory common.Address `json:"factory"`
+	// Deployer is the address creating pools with CREATE2, the factory is used if it is empty
+	Deployer     common.Address `json:"deployer,omitempty"`
+	InitCodeHash common.Hash    `json:"initCodeHash"`
+	// FeeTiers are the enabled fee tiers of v3 DEX
+	FeeTiers []int `json:"feeTiers,omitempty"`
+	// FeeBps is the swap fee of v2 DEX in basis points
+	FeeBps int `json:"feeBps,omitempty"`
+}
+
+// Validate check the DEX fields
+func (d Dex) Validate() error {
+	if d.Name == "" {
+		return errors.New("dex name is empty")
+	}
+	if d.ChainID == 0 {
+		return errors.Errorf("dex %s chain id is zero", d.Name)
+	}
+	if d.Factory == (common.Address{}) {
+		return errors.Errorf("dex %s factory is empty", d.Name)
+	}
+	if d.InitCodeHash == (common.Hash{}) {
+		return errors.Errorf("dex %s init code hash is empty", d.Name)
+	}
+	switch d.Version {
+	case VersionV2:
+		if d.FeeBps <= 0 || d.FeeBps >= BpsDenominator {
+			return errors.Errorf("dex %s fee bps %d out of range", d.Name, d.FeeBps)
+		}
+	case VersionV3:
+		if len(d.FeeTiers) == 0 {
+			return errors.Errorf("dex %s has no fee tiers", d.Name)
+		}
+	default:
+		return errors.Errorf("dex %s unknown version %q", d.Name, d.Version)
+	}
+	return nil
+}
+
+// PoolAddress calculate pool address of tokens offline, fee is ignored by v2 DEX
+func (d Dex) PoolAddress(tokenA, tokenB common.Address, fee int) (poolAddr common.Address, err error) {
+	initCode := hex.EncodeToString(d.InitCodeHash.Bytes())
+	if d.Version == VersionV2 {
+		return CalculatePoolAddress(tokenA, tokenB, d.Factory, initCode)
+	}
+	if !containsFee(d.FeeTiers, fee) {
+		err = errors.Wrapf(ErrFeeTierNotEnabled, "dex %s fee %d", d.Name, fee)
+		return
+	}
+	deployer := d.Deployer
+	if deployer == (common.Address{}) {
+		deployer = d.Factory
+	}
+	return CalculatePoolAddressV3Like(tokenA, tokenB, big.NewInt(int64(fee)), deployer, initCode)
+}
+
+type registryKey struct {
+	name    string
+	chainID uint64
+}
+
+// Registry is a concurrent safe set of DEX deployments keyed by name and chain id
+type Registry struct {
+	mu    sync.RWMutex
+	dexes map[registryKey]Dex
+}
+
+// NewRegistry creates a registry with the known DEX deployments
+func NewRegistry() *Registry {
+	r := NewEmptyRegistry()
+	for _, dex := range knownDexes() {
+		if err := r.Register(dex); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// NewEmptyRegistry creates a registry without any DEX deployment
+func NewEmptyRegistry() *Registry {
+	return &Registry{dexes: map[registryKey]Dex{}}
+}
+
+// Register add or replace a DEX deployment
+func (r *Registry) Register(dex Dex) error {
+	if err := dex.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dexes[registryKey{dex.Name, dex.ChainID}] = dex
+	return nil
+}
+
+// LoadJSON register DEX deployments from a JSON array of Dex
+func (r *Registry) LoadJSON(data []byte) error {
+	var dexes []Dex
+	if err := json.Unmarshal(data, &dexes); err != nil {
+		return errors.Wrap(err, "unmarshal dex config")
+	}
+	for _, dex := range dexes {
+		if err := r.Register(dex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lookup returns the DEX deployment by name and chain id
+func (r *Registry) Lookup(name string, chainID uint64) (dex Dex, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	dex, ok := r.dexes[registryKey{name, chainID}]
+	if !ok {
+		err = errors.Wrapf(ErrDexNotFound, "dex %s chain %d", name, chainID)
+	}
+	return
+}
+
+// Dexes returns the DEX deployments on chain sorted by name
+func (r *Registry) Dexes(chainID uint64) []Dex {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	dexes := make([]Dex, 0)
+	for key, dex := range r.dexes {
+		if key.chainID == chainID {
+			dexes = append(dexes, dex)
+		}
+	}
+	sort.Slice(dexes, func(i, j int) bool { return dexes[i].Name < dexes[j].Name })
+	return dexes
+}
+
+// PoolAddress calculate pool address of tokens on the DEX deployment offline, fee is ignored by v2 DEX
+func (r *Registry) PoolAddress(name string, chainID uint64, tokenA, tokenB common.Address, fee int) (poolAddr common.Address, err error) {
+	dex, err := r.Lookup(name, chainID)
+	if err != nil {
+		return
+	}
+	return dex.PoolAddress(tokenA, tokenB, fee)
+}
+
+func knownDexes() []Dex {
+	var (
+		uniswapV2InitCode = common.HexToHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f")
+		uniswapV3InitCode = common.HexToHash("0xe34f199b19b2b4f47f68442619d555527d244f78a3297ea89325f843f87b8b54")
+		sushiswapInitCode = common.HexToHash("0xe18a34eb0e04b04f7a0ac29a6e80748dca96319b42c54d679cb821dca90c6303")
+		pancakeV2InitCode = common.HexToHash("0x00fb7f630766e6a796048ea87d01acd3068e8ff67d078148a3fa3f4a84f69bd5")
+		pancakeV3InitCode = common.HexToHash("0x6ce8eb472fa82df5469c6ab6d485f17c3ad13c8cd7af59b3d4a8026c5ce0f7e2")
+		traderJoeInitCode = common.HexToHash("0x0bbca9af0511ad1a1da383135cf3a8d2ac620e549ef9f6ae3a4c33c2fed0af91")
+		uniswapV3Factory  = common.HexToAddress(FactoryAddrV3)
+		sushiswapFactory  = common.HexToAddress("0xc35DADB65012eC5796536bD9864eD8773aBc74C4")
+		pancakeV3Factory  = common.HexToAddress("0x0BFbCF9fa4f9C56B0F40a671Ad40E0805A091865")
+		pancakeV3Deployer = common.HexToAddress("0x41ff9AA7e16B8B1a8a8dc4f0eFacd93D02d071c9")
+		pancakeV3FeeTiers = []int{100, 500, 2500, 10000}
+		uniswapV3OnChain  = func(chainID uint64, factory common.Address) Dex {
+			return Dex{Name: DexUniswapV3, ChainID: chainID, Version: VersionV3, Factory: factory, InitCodeHash: uniswapV3InitCode, FeeTiers: FeeTiersV3}
+		}
+		sushiswapOnChain = func(chainID uint64, factory common.Address) Dex {
+			return Dex{Name: DexSushiswap, ChainID: chainID, Version: VersionV2, Factory: factory, InitCodeHash: sushiswapInitCode, FeeBps: FeeBpsV2}
+		}
+		pancakeV3OnChain = func(chainID uint64) Dex {
+			return Dex{Name: DexPancakeSwapV3, ChainID: chainID, Version: VersionV3, Factory: pancakeV3Factory, Deployer: pancakeV3Deployer, InitCodeHash: pancakeV3InitCode, FeeTiers: pancakeV3FeeTiers}
+		}
+	)
+
+	return []Dex{
+		{Name: DexUniswapV2, ChainID: ChainIDEthereum, Version: VersionV2, Factory: common.HexToAddress(FactoryAddrV2), InitCodeHash: uniswapV2InitCode, FeeBps: FeeBpsV2},
+		uniswapV3OnChain(ChainIDEthereum, uniswapV3Factory),
+		uniswapV3OnChain(ChainIDOptimism, uniswapV3Factory),
+		uniswapV3OnChain(ChainIDPolygon, uniswapV3Factory),
+		uniswapV3OnChain(ChainIDArbitrum, uniswapV3Factory),
+		uniswapV3OnChain(ChainIDBSC, common.HexToAddress("0xdB1d10011AD0Ff90774D0C6Bb92e5C5c8b4461F7")),
+		uniswapV3OnChain(ChainIDBase, common.HexToAddress("0x33128a8fC17869897dcE68Ed026d694621f6FDfD")),
+		sushiswapOnChain(ChainIDEthereum, common.HexToAddress("0xC0AEe478e3658e2610c5F7A4A2E1777cE9e4f2Ac")),
+		sushiswapOnChain(ChainIDBSC, sushiswapFactory),
+		sushiswapOnChain(ChainIDPolygon, sushiswapFactory),
+		sushiswapOnChain(ChainIDArbitrum, sushiswapFactory),
+		sushiswapOnChain(ChainIDAvalanche, sushiswapFactory),
+		{Name: DexPancakeSwapV2, ChainID: ChainIDBSC, Version: VersionV2, Factory: common.HexToAddress("0xcA143Ce32Fe78f1f7019d7d551a6402fC5350c73"), InitCodeHash: pancakeV2InitCode, FeeBps: FeeBpsPancakeV2},
+		pancakeV3OnChain(ChainIDEthereum),
+		pancakeV3OnChain(ChainIDBSC),
+		{Name: DexQuickSwap, ChainID: ChainIDPolygon, Version: VersionV2, Factory: common.HexToAddress("0x5757371414417b8C6CAad45bAeF941aBc7d3Ab32"), InitCodeHash: uniswapV2InitCode, FeeBps: FeeBpsV2},
+		{Name: DexTraderJoe, ChainID: ChainIDAvalanche, Version: VersionV2, Factory: common.HexToAddress("0x9Ad6C38BE94206cA50bb0d90783181662f0Cfa10"), InitCodeHash: traderJoeInitCode, FeeBps: FeeBpsV2},
+	}
+}
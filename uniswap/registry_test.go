@@ -0,0 +1,59 @@
+package uniswap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryPoolAddress(t *testing.T) {
+	wbnb := common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c")
+	busd := common.HexToAddress("0xe9e7CEA3DedcA5984780Bafc599bD69ADd087D56")
+	usdt := common.HexToAddress("0x55d398326f99059fF775485246999027B3197955")
+	weth := common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	usdc := common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	dai := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+
+	pair, err := DefaultRegistry.PoolAddress(DexPancakeSwapV2, ChainIDBSC, wbnb, busd, 0)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, common.HexToAddress("0x58F876857a02D6762E0101bb5C46A8c1ED44Dc16"), pair)
+
+	pair, _ = DefaultRegistry.PoolAddress(DexSushiswap, ChainIDEthereum, weth, usdc, 0)
+	assert.Equal(t, common.HexToAddress("0x397FF1542f962076d0BFE58eA045FfA2d347ACa0"), pair)
+
+	pool, _ := DefaultRegistry.PoolAddress(DexUniswapV3, ChainIDEthereum, weth, dai, 3000)
+	expected, _ := CalculatePoolAddressV3(weth.String(), dai.String(), big.NewInt(3000))
+	assert.Equal(t, expected, pool)
+
+	pool, _ = DefaultRegistry.PoolAddress(DexPancakeSwapV3, ChainIDBSC, usdt, wbnb, 500)
+	assert.Equal(t, common.HexToAddress("0x36696169C63e42cd08ce11f5deeBbCeBae652050"), pool)
+
+	_, err = DefaultRegistry.PoolAddress(DexPancakeSwapV3, ChainIDBSC, usdt, wbnb, 3000)
+	assert.ErrorIs(t, err, ErrFeeTierNotEnabled)
+	_, err = DefaultRegistry.PoolAddress(DexPancakeSwapV2, ChainIDEthereum, wbnb, busd, 0)
+	assert.ErrorIs(t, err, ErrDexNotFound)
+}
+
+func TestRegistryLoadJSON(t *testing.T) {
+	r := NewEmptyRegistry()
+	config := `[{"name":"uniswap-v2","chainId":1,"version":"v2","factory":"0x5c69bee701ef814a2b6a3edd4b1652cb9cc5aa6f","initCodeHash":"0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f","feeBps":30}]`
+	if err := r.LoadJSON([]byte(config)); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(r.Dexes(ChainIDEthereum)))
+
+	weth := common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	dai := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	pair, err := r.PoolAddress(DexUniswapV2, ChainIDEthereum, weth, dai, 0)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, common.HexToAddress("0xA478c2975Ab1Ea89e8196811F51A7B7Ade33eB11"), pair)
+
+	invalid := `[{"name":"broken","chainId":1,"version":"v4","factory":"0x5c69bee701ef814a2b6a3edd4b1652cb9cc5aa6f","initCodeHash":"0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"}]`
+	assert.NotEqual(t, nil, r.LoadJSON([]byte(invalid)))
+}
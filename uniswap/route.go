@@ -0,0 +1,231 @@
+package uniswap
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Gas estimates of router swaps used to rank routes
+const (
+	GasSwapBase         = 90000
+	GasPerHopV2         = 60000
+	GasPerHopV3         = 80000
+	GasPerTickCrossedV3 = 30000
+
+	// DefaultMaxHops is the maximum number of pools of a route when RouteOptions.MaxHops is zero
+	DefaultMaxHops = 3
+)
+
+var ErrNoRoute = errors.New("no route found")
+
+// CandidatePool is a v2 pair or v3 pool the route finder can swap through
+type CandidatePool struct {
+	Address common.Address
+	Version string
+	Token0  common.Address
+	Token1  common.Address
+	// Pair and FeeBps are the reserves and swap fee of v2 pair
+	Pair   *PairV2
+	FeeBps int
+	// State is the state of v3 pool, State.Fee is the fee tier of the pool
+	State *PoolState
+}
+
+// NewCandidatePoolV2 creates candidate of v2 pair with swap fee feeBps,
+// the pair address is calculated when pair.Address is not set
+func NewCandidatePoolV2(pair *PairV2, feeBps int) (pool CandidatePool, err error) {
+	address := pair.Address
+	if address == (common.Address{}) {
+		address, err = CalculatePoolAddressV2(pair.Token0.Hex(), pair.Token1.Hex())
+		if err != nil {
+			return
+		}
+	}
+	pool = CandidatePool{Address: address, Version: VersionV2, Token0: pair.Token0, Token1: pair.Token1, Pair: pair, FeeBps: feeBps}
+	return
+}
+
+// NewCandidatePoolV3 creates candidate of uniswap v3 pool of tokenA and tokenB with state
+func NewCandidatePoolV3(tokenA, tokenB common.Address, state *PoolState) (pool CandidatePool, err error) {
+	token0, token1 := sortAddressess(tokenA, tokenB)
+	address, err := CalculatePoolAddressV3(token0.Hex(), token1.Hex(), big.NewInt(int64(state.Fee)))
+	if err != nil {
+		return
+	}
+	pool = CandidatePool{Address: address, Version: VersionV3, Token0: token0, Token1: token1, State: state}
+	return
+}
+
+// other returns the token swapped against token, ok is false if the pool does not contain token
+func (p CandidatePool) other(token common.Address) (other common.Address, ok bool) {
+	switch token {
+	case p.Token0:
+		return p.Token1, true
+	case p.Token1:
+		return p.Token0, true
+	}
+	return
+}
+
+// quote calculate the amount out of swapping amountIn of tokenIn and the estimated gas of the hop
+func (p CandidatePool) quote(tokenIn common.Address, amountIn *big.Int) (amountOut *big.Int, gas uint64, err error) {
+	if p.Version == VersionV2 {
+		reserveIn, reserveOut, err := p.Pair.Reserves(tokenIn)
+		if err != nil {
+			return nil, 0, err
+		}
+		amountOut, err = GetAmountOutV2(amountIn, reserveIn, reserveOut, p.FeeBps)
+		return amountOut, GasPerHopV2, err
+	}
+
+	result, err := SimulateSwap(p.State, tokenIn == p.Token0, amountIn, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	// the swap stops at the price limit when the pool runs out of liquidity
+	if result.AmountIn.Cmp(amountIn) != 0 {
+		return nil, 0, ErrInsufficientLiquidity
+	}
+	return result.AmountOut, GasPerHopV3 + GasPerTickCrossedV3*uint64(len(result.TicksCrossed)), nil
+}
+
+// RouteOptions configure the route finder
+type RouteOptions struct {
+	// MaxHops is the maximum number of pools of a route, DefaultMaxHops if zero
+	MaxHops int
+	// GasPrice is the gas price in wei, gas is not deducted from the output if nil
+	GasPrice *big.Int
+	// NativePrice is the amount of tokenOut in its smallest unit worth 1 ether of native currency,
+	// used to convert the gas cost to tokenOut
+	NativePrice decimal.Decimal
+}
+
+// Route is a swap route through one or more pools of the same version
+type Route struct {
+	Path      []common.Address
+	Pools     []CandidatePool
+	AmountIn  *big.Int
+	AmountOut *big.Int
+	// Gas is the estimated gas of the swap, GasCost is its cost in tokenOut
+	Gas     uint64
+	GasCost *big.Int
+	// NetAmountOut is AmountOut less GasCost, routes are ranked by it
+	NetAmountOut *big.Int
+}
+
+// Version returns the version of the pools of route
+func (r *Route) Version() string {
+	return r.Pools[0].Version
+}
+
+// Fees returns the fee tiers of the v3 pools of route
+func (r *Route) Fees() []int {
+	fees := make([]int, len(r.Pools))
+	for i, pool := range r.Pools {
+		if pool.State != nil {
+			fees[i] = pool.State.Fee
+		}
+	}
+	return fees
+}
+
+// EncodedPathV3 returns the route encoded for v3 exact input swaps, see EncodePath
+func (r *Route) EncodedPathV3() ([]byte, error) {
+	if r.Version() != VersionV3 {
+		return nil, errors.Errorf("route of %s pools has no v3 path", r.Version())
+	}
+	return EncodePath(r.Path, r.Fees())
+}
+
+// PathV2 returns the token path of the route for v2 router swaps
+func (r *Route) PathV2() ([]common.Address, error) {
+	if r.Version() != VersionV2 {
+		return nil, errors.Errorf("route of %s pools has no v2 path", r.Version())
+	}
+	return r.Path, nil
+}
+
+// FindRoutes enumerate the routes from tokenIn to tokenOut through pools of the same version with at most
+// opts.MaxHops hops, routes are sorted by output net of estimated gas from the best. Routes through pools
+// without enough liquidity or tick data are skipped.
+func FindRoutes(tokenIn, tokenOut common.Address, amountIn *big.Int, pools []CandidatePool, opts RouteOptions) (routes []*Route, err error) {
+	if tokenIn == tokenOut {
+		err = errors.New("token in and token out are the same")
+		return
+	}
+	if amountIn == nil || amountIn.Sign() <= 0 {
+		err = ErrInsufficientInputAmount
+		return
+	}
+	maxHops := opts.MaxHops
+	if maxHops <= 0 {
+		maxHops = DefaultMaxHops
+	}
+
+	routes = make([]*Route, 0)
+	visited := map[common.Address]bool{tokenIn: true}
+	var search func(token common.Address, amount *big.Int, gas uint64, path []common.Address, hops []CandidatePool)
+	search = func(token common.Address, amount *big.Int, gas uint64, path []common.Address, hops []CandidatePool) {
+		for _, pool := range pools {
+			next, ok := pool.other(token)
+			if !ok || visited[next] || (len(hops) > 0 && pool.Version != hops[0].Version) {
+				continue
+			}
+			amountOut, hopGas, err := pool.quote(token, amount)
+			if err != nil || amountOut.Sign() == 0 {
+				continue
+			}
+
+			nextPath := append(append(make([]common.Address, 0, len(path)+1), path...), next)
+			nextHops := append(append(make([]CandidatePool, 0, len(hops)+1), hops...), pool)
+			if next == tokenOut {
+				routes = append(routes, newRoute(nextPath, nextHops, amountIn, amountOut, GasSwapBase+gas+hopGas, opts))
+				continue
+			}
+			if len(nextHops) < maxHops {
+				visited[next] = true
+				search(next, amountOut, gas+hopGas, nextPath, nextHops)
+				visited[next] = false
+			}
+		}
+	}
+	search(tokenIn, amountIn, 0, []common.Address{tokenIn}, nil)
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].NetAmountOut.Cmp(routes[j].NetAmountOut) > 0
+	})
+	return
+}
+
+// BestRoute returns the route with the highest output net of estimated gas, see FindRoutes
+func BestRoute(tokenIn, tokenOut common.Address, amountIn *big.Int, pools []CandidatePool, opts RouteOptions) (*Route, error) {
+	routes, err := FindRoutes(tokenIn, tokenOut, amountIn, pools, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return nil, errors.Wrapf(ErrNoRoute, "%s to %s", tokenIn, tokenOut)
+	}
+	return routes[0], nil
+}
+
+func newRoute(path []common.Address, pools []CandidatePool, amountIn, amountOut *big.Int, gas uint64, opts RouteOptions) *Route {
+	gasCost := new(big.Int)
+	if opts.GasPrice != nil {
+		wei := new(big.Int).Mul(new(big.Int).SetUint64(gas), opts.GasPrice)
+		gasCost = decimal.NewFromBigInt(wei, -18).Mul(opts.NativePrice).BigInt()
+	}
+	return &Route{
+		Path:         path,
+		Pools:        pools,
+		AmountIn:     amountIn,
+		AmountOut:    amountOut,
+		Gas:          gas,
+		GasCost:      gasCost,
+		NetAmountOut: new(big.Int).Sub(amountOut, gasCost),
+	}
+}
@@ -0,0 +1,76 @@
+package uniswap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindRoutes(t *testing.T) {
+	tokenA := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	tokenB := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	tokenC := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	deep, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	shallow, _ := new(big.Int).SetString("1000000000000000000", 10)
+	amountIn, _ := new(big.Int).SetString("10000000000000000", 10)
+
+	pools := make([]CandidatePool, 0)
+	for _, pair := range []*PairV2{
+		{Token0: tokenA, Token1: tokenB, Reserve0: deep, Reserve1: deep},
+		{Token0: tokenB, Token1: tokenC, Reserve0: deep, Reserve1: deep},
+		{Token0: tokenA, Token1: tokenC, Reserve0: shallow, Reserve1: shallow},
+	} {
+		pool, err := NewCandidatePoolV2(pair, FeeBpsV2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pools = append(pools, pool)
+	}
+	poolV3, err := NewCandidatePoolV3(tokenC, tokenA, testPoolState())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, tokenA, poolV3.Token0)
+	pools = append(pools, poolV3)
+
+	routes, err := FindRoutes(tokenA, tokenC, amountIn, pools, RouteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a-c v2, a-b-c v2 and a-c v3, mixed versions are not combined
+	assert.Equal(t, 3, len(routes))
+	best := routes[0]
+	assert.Equal(t, []common.Address{tokenA, tokenB, tokenC}, best.Path)
+	path, err := best.PathV2()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, best.Path, path)
+	_, err = best.EncodedPathV3()
+	assert.NotEqual(t, nil, err)
+	amounts, _ := GetAmountsOutV2(amountIn, path, []*PairV2{pools[0].Pair, pools[1].Pair}, FeeBpsV2)
+	assert.Equal(t, amounts[2], best.AmountOut)
+	assert.Equal(t, uint64(GasSwapBase+2*GasPerHopV2), best.Gas)
+
+	// the extra hop costs more than it gains once gas is priced
+	best, err = BestRoute(tokenA, tokenC, amountIn, pools, RouteOptions{
+		GasPrice:    big.NewInt(1000000000),
+		NativePrice: decimal.New(1, 18),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, VersionV3, best.Version())
+	assert.Equal(t, new(big.Int).Mul(new(big.Int).SetUint64(best.Gas), big.NewInt(1000000000)), best.GasCost)
+	encoded, err := best.EncodedPathV3()
+	assert.Equal(t, nil, err)
+	expected, _ := EncodePath([]common.Address{tokenA, tokenC}, []int{3000})
+	assert.Equal(t, expected, encoded)
+
+	routes, _ = FindRoutes(tokenA, tokenC, amountIn, pools, RouteOptions{MaxHops: 1})
+	assert.Equal(t, 2, len(routes))
+
+	_, err = BestRoute(tokenA, common.HexToAddress("0x0000000000000000000000000000000000000004"), amountIn, pools, RouteOptions{})
+	assert.ErrorIs(t, err, ErrNoRoute)
+}
@@ -0,0 +1,335 @@
+package uniswap
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+const (
+	SwapRouter02Addr = "0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45"
+	RouterV2Addr     = "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+
+	SwapRouter02Abi = `[{"inputs":[{"components":[{"internalType":"address","name":"tokenIn","type":"address"},{"internalType":"address","name":"tokenOut","type":"address"},{"internalType":"uint24","name":"fee","type":"uint24"},{"internalType":"address","name":"recipient","type":"address"},{"internalType":"uint256","name":"amountIn","type":"uint256"},{"internalType":"uint256","name":"amountOutMinimum","type":"uint256"},{"internalType":"uint160","name":"sqrtPriceLimitX96","type":"uint160"}],"internalType":"struct IV3SwapRouter.ExactInputSingleParams","name":"params","type":"tuple"}],"name":"exactInputSingle","outputs":[{"internalType":"uint256","name":"amountOut","type":"uint256"}],"stateMutability":"payable","type":"function"},{"inputs":[{"components":[{"internalType":"bytes","name":"path","type":"bytes"},{"internalType":"address","name":"recipient","type":"address"},{"internalType":"uint256","name":"amountIn","type":"uint256"},{"internalType":"uint256","name":"amountOutMinimum","type":"uint256"}],"internalType":"struct IV3SwapRouter.ExactInputParams","name":"params","type":"tuple"}],"name":"exactInput","outputs":[{"internalType":"uint256","name":"amountOut","type":"uint256"}],"stateMutability":"payable","type":"function"},{"inputs":[{"components":[{"internalType":"address","name":"tokenIn","type":"address"},{"internalType":"address","name":"tokenOut","type":"address"},{"internalType":"uint24","name":"fee","type":"uint24"},{"internalType":"address","name":"recipient","type":"address"},{"internalType":"uint256","name":"amountOut","type":"uint256"},{"internalType":"uint256","name":"amountInMaximum","type":"uint256"},{"internalType":"uint160","name":"sqrtPriceLimitX96","type":"uint160"}],"internalType":"struct IV3SwapRouter.ExactOutputSingleParams","name":"params","type":"tuple"}],"name":"exactOutputSingle","outputs":[{"internalType":"uint256","name":"amountIn","type":"uint256"}],"stateMutability":"payable","type":"function"},{"inputs":[{"components":[{"internalType":"bytes","name":"path","type":"bytes"},{"internalType":"address","name":"recipient","type":"address"},{"internalType":"uint256","name":"amountOut","type":"uint256"},{"internalType":"uint256","name":"amountInMaximum","type":"uint256"}],"internalType":"struct IV3SwapRouter.ExactOutputParams","name":"params","type":"tuple"}],"name":"exactOutput","outputs":[{"internalType":"uint256","name":"amountIn","type":"uint256"}],"stateMutability":"payable","type":"function"},{"inputs":[{"internalType":"uint256","name":"deadline","type":"uint256"},{"internalType":"bytes[]","name":"data","type":"bytes[]"}],"name":"multicall","outputs":[{"internalType":"bytes[]","name":"","type":"bytes[]"}],"stateMutability":"payable","type":"function"},{"inputs":[],"name":"refundETH","outputs":[],"stateMutability":"payable","type":"function"}]`
+	RouterV2Abi     = `[{"inputs":[{"internalType":"uint256","name":"amountIn","type":"uint256"},{"internalType":"uint256","name":"amountOutMin","type":"uint256"},{"internalType":"address[]","name":"path","type":"address[]"},{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"deadline","type":"uint256"}],"name":"swapExactTokensForTokens","outputs":[{"internalType":"uint256[]","name":"amounts","type":"uint256[]"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"uint256","name":"amountOut","type":"uint256"},{"internalType":"uint256","name":"amountInMax","type":"uint256"},{"internalType":"address[]","name":"path","type":"address[]"},{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"deadline","type":"uint256"}],"name":"swapTokensForExactTokens","outputs":[{"internalType":"uint256[]","name":"amounts","type":"uint256[]"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"uint256","name":"amountOutMin","type":"uint256"},{"internalType":"address[]","name":"path","type":"address[]"},{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"deadline","type":"uint256"}],"name":"swapExactETHForTokens","outputs":[{"internalType":"uint256[]","name":"amounts","type":"uint256[]"}],"stateMutability":"payable","type":"function"},{"inputs":[{"internalType":"uint256","name":"amountOut","type":"uint256"},{"internalType":"address[]","name":"path","type":"address[]"},{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"deadline","type":"uint256"}],"name":"swapETHForExactTokens","outputs":[{"internalType":"uint256[]","name":"amounts","type":"uint256[]"}],"stateMutability":"payable","type":"function"},{"inputs":[{"internalType":"uint256","name":"amountIn","type":"uint256"},{"internalType":"uint256","name":"amountOutMin","type":"uint256"},{"internalType":"address[]","name":"path","type":"address[]"},{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"deadline","type":"uint256"}],"name":"swapExactTokensForETH","outputs":[{"internalType":"uint256[]","name":"amounts","type":"uint256[]"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"uint256","name":"amountOut","type":"uint256"},{"internalType":"uint256","name":"amountInMax","type":"uint256"},{"internalType":"address[]","name":"path","type":"address[]"},{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"deadline","type":"uint256"}],"name":"swapTokensForExactETH","outputs":[{"internalType":"uint256[]","name":"amounts","type":"uint256[]"}],"stateMutability":"nonpayable","type":"function"}]`
+
+	// DefaultSwapDeadline is the lifetime of swap transactions without deadline
+	DefaultSwapDeadline = 20 * time.Minute
+)
+
+var swapRouter02Abi = mustParseAbi(SwapRouter02Abi)
+
+// SwapParams are the common parameters of swap transactions
+type SwapParams struct {
+	// Recipient receives the output tokens, the sender is used if it is empty
+	Recipient common.Address
+	// Deadline is the time after which the swap reverts, DefaultSwapDeadline from now is used if it is zero
+	Deadline time.Time
+	// SlippageBps is applied to quoted amounts to get the minimum output or maximum input amount
+	SlippageBps int
+}
+
+func (p SwapParams) deadline() *big.Int {
+	deadline := p.Deadline
+	if deadline.IsZero() {
+		deadline = time.Now().Add(DefaultSwapDeadline)
+	}
+	return big.NewInt(deadline.Unix())
+}
+
+func (p SwapParams) recipient(from common.Address) common.Address {
+	if p.Recipient == (common.Address{}) {
+		return from
+	}
+	return p.Recipient
+}
+
+// ExactInputSingleParams is the parameter of SwapRouter02.exactInputSingle
+type ExactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	AmountIn          *big.Int
+	AmountOutMinimum  *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+// ExactInputParams is the parameter of SwapRouter02.exactInput
+type ExactInputParams struct {
+	Path             []byte
+	Recipient        common.Address
+	AmountIn         *big.Int
+	AmountOutMinimum *big.Int
+}
+
+// ExactOutputSingleParams is the parameter of SwapRouter02.exactOutputSingle
+type ExactOutputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	AmountOut         *big.Int
+	AmountInMaximum   *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+// ExactOutputParams is the parameter of SwapRouter02.exactOutput
+type ExactOutputParams struct {
+	Path            []byte
+	Recipient       common.Address
+	AmountOut       *big.Int
+	AmountInMaximum *big.Int
+}
+
+// SwapRouter02 builds signed swap transactions of uniswap v3 SwapRouter02.
+// To swap from native currency set opts.Value to the input amount and use WETH9 as tokenIn.
+type SwapRouter02 struct {
+	client  *ethclient.Client
+	Address common.Address
+}
+
+// NewSwapRouter02 creates SwapRouter02 transaction builder of router address
+func NewSwapRouter02(client *ethclient.Client, router common.Address) *SwapRouter02 {
+	return &SwapRouter02{client: client, Address: router}
+}
+
+// ExactInputSingle build exactInputSingle transaction swapping amountIn of tokenIn to tokenOut,
+// amountOutMinimum is quotedAmountOut less slippage
+func (r *SwapRouter02) ExactInputSingle(privKey string, opts *bind.TransactOpts, tokenIn, tokenOut common.Address, fee int, amountIn, quotedAmountOut *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amountOutMinimum, err := MinimumAmountOut(quotedAmountOut, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	call, err := swapRouter02Abi.Pack("exactInputSingle", ExactInputSingleParams{
+		TokenIn:           tokenIn,
+		TokenOut:          tokenOut,
+		Fee:               big.NewInt(int64(fee)),
+		Recipient:         params.recipient(opts.From),
+		AmountIn:          amountIn,
+		AmountOutMinimum:  amountOutMinimum,
+		SqrtPriceLimitX96: new(big.Int),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "pack exactInputSingle")
+	}
+	return r.multicall(privKey, opts, params, call)
+}
+
+// ExactInput build exactInput transaction swapping amountIn of path[0] to the last token of path,
+// amountOutMinimum is quotedAmountOut less slippage
+func (r *SwapRouter02) ExactInput(privKey string, opts *bind.TransactOpts, path []common.Address, fees []int, amountIn, quotedAmountOut *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amountOutMinimum, err := MinimumAmountOut(quotedAmountOut, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	encodedPath, err := EncodePath(path, fees)
+	if err != nil {
+		return nil, err
+	}
+	call, err := swapRouter02Abi.Pack("exactInput", ExactInputParams{
+		Path:             encodedPath,
+		Recipient:        params.recipient(opts.From),
+		AmountIn:         amountIn,
+		AmountOutMinimum: amountOutMinimum,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "pack exactInput")
+	}
+	return r.multicall(privKey, opts, params, call)
+}
+
+// ExactOutputSingle build exactOutputSingle transaction swapping tokenIn to amountOut of tokenOut,
+// amountInMaximum is quotedAmountIn plus slippage
+func (r *SwapRouter02) ExactOutputSingle(privKey string, opts *bind.TransactOpts, tokenIn, tokenOut common.Address, fee int, amountOut, quotedAmountIn *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amountInMaximum, err := MaximumAmountIn(quotedAmountIn, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	call, err := swapRouter02Abi.Pack("exactOutputSingle", ExactOutputSingleParams{
+		TokenIn:           tokenIn,
+		TokenOut:          tokenOut,
+		Fee:               big.NewInt(int64(fee)),
+		Recipient:         params.recipient(opts.From),
+		AmountOut:         amountOut,
+		AmountInMaximum:   amountInMaximum,
+		SqrtPriceLimitX96: new(big.Int),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "pack exactOutputSingle")
+	}
+	return r.multicall(privKey, opts, params, call)
+}
+
+// ExactOutput build exactOutput transaction swapping path[0] to amountOut of the last token of path,
+// path is in swap order and encoded reversed as the router expects. amountInMaximum is quotedAmountIn plus slippage
+func (r *SwapRouter02) ExactOutput(privKey string, opts *bind.TransactOpts, path []common.Address, fees []int, amountOut, quotedAmountIn *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amountInMaximum, err := MaximumAmountIn(quotedAmountIn, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	encodedPath, err := EncodePathReversed(path, fees)
+	if err != nil {
+		return nil, err
+	}
+	call, err := swapRouter02Abi.Pack("exactOutput", ExactOutputParams{
+		Path:            encodedPath,
+		Recipient:       params.recipient(opts.From),
+		AmountOut:       amountOut,
+		AmountInMaximum: amountInMaximum,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "pack exactOutput")
+	}
+	return r.multicall(privKey, opts, params, call)
+}
+
+// multicall wrap calls in multicall with deadline, SwapRouter02 swap methods have no deadline themselves.
+// refundETH is appended when the transaction carries value so that unspent ETH is returned.
+func (r *SwapRouter02) multicall(privKey string, opts *bind.TransactOpts, params SwapParams, calls ...[]byte) (*types.Transaction, error) {
+	if opts.Value != nil && opts.Value.Sign() > 0 {
+		refund, err := swapRouter02Abi.Pack("refundETH")
+		if err != nil {
+			return nil, errors.Wrap(err, "pack refundETH")
+		}
+		calls = append(calls, refund)
+	}
+	return r.client.BuildContractTx(privKey, "multicall", SwapRouter02Abi, &r.Address, opts, params.deadline(), calls)
+}
+
+// RouterV2 builds signed swap transactions of uniswapV2 like Router02
+type RouterV2 struct {
+	client  *ethclient.Client
+	Address common.Address
+}
+
+// NewRouterV2 creates uniswapV2 like router transaction builder of router address
+func NewRouterV2(client *ethclient.Client, router common.Address) *RouterV2 {
+	return &RouterV2{client: client, Address: router}
+}
+
+// SwapExactTokensForTokens build swapExactTokensForTokens transaction, amountOutMin is quotedAmountOut less slippage
+func (r *RouterV2) SwapExactTokensForTokens(privKey string, opts *bind.TransactOpts, path []common.Address, amountIn, quotedAmountOut *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amountOutMin, err := MinimumAmountOut(quotedAmountOut, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	return r.client.BuildContractTx(privKey, "swapExactTokensForTokens", RouterV2Abi, &r.Address, opts,
+		amountIn, amountOutMin, path, params.recipient(opts.From), params.deadline())
+}
+
+// SwapTokensForExactTokens build swapTokensForExactTokens transaction, amountInMax is quotedAmountIn plus slippage
+func (r *RouterV2) SwapTokensForExactTokens(privKey string, opts *bind.TransactOpts, path []common.Address, amountOut, quotedAmountIn *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amountInMax, err := MaximumAmountIn(quotedAmountIn, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	return r.client.BuildContractTx(privKey, "swapTokensForExactTokens", RouterV2Abi, &r.Address, opts,
+		amountOut, amountInMax, path, params.recipient(opts.From), params.deadline())
+}
+
+// SwapExactETHForTokens build swapExactETHForTokens transaction sending amountIn of native currency,
+// path[0] must be the wrapped native token. amountOutMin is quotedAmountOut less slippage
+func (r *RouterV2) SwapExactETHForTokens(privKey string, opts *bind.TransactOpts, path []common.Address, amountIn, quotedAmountOut *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amountOutMin, err := MinimumAmountOut(quotedAmountOut, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	opts.Value = amountIn
+	return r.client.BuildContractTx(privKey, "swapExactETHForTokens", RouterV2Abi, &r.Address, opts,
+		amountOutMin, path, params.recipient(opts.From), params.deadline())
+}
+
+// SwapETHForExactTokens build swapETHForExactTokens transaction sending quotedAmountIn plus slippage of native currency,
+// the router refunds unspent value. path[0] must be the wrapped native token
+func (r *RouterV2) SwapETHForExactTokens(privKey string, opts *bind.TransactOpts, path []common.Address, amountOut, quotedAmountIn *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amountInMax, err := MaximumAmountIn(quotedAmountIn, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	opts.Value = amountInMax
+	return r.client.BuildContractTx(privKey, "swapETHForExactTokens", RouterV2Abi, &r.Address, opts,
+		amountOut, path, params.recipient(opts.From), params.deadline())
+}
+
+// SwapExactTokensForETH build swapExactTokensForETH transaction, the last token of path must be the wrapped native token.
+// amountOutMin is quotedAmountOut less slippage
+func (r *RouterV2) SwapExactTokensForETH(privKey string, opts *bind.TransactOpts, path []common.Address, amountIn, quotedAmountOut *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amountOutMin, err := MinimumAmountOut(quotedAmountOut, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	return r.client.BuildContractTx(privKey, "swapExactTokensForETH", RouterV2Abi, &r.Address, opts,
+		amountIn, amountOutMin, path, params.recipient(opts.From), params.deadline())
+}
+
+// SwapTokensForExactETH build swapTokensForExactETH transaction, the last token of path must be the wrapped native token.
+// amountInMax is quotedAmountIn plus slippage
+func (r *RouterV2) SwapTokensForExactETH(privKey string, opts *bind.TransactOpts, path []common.Address, amountOut, quotedAmountIn *big.Int, params SwapParams) (*types.Transaction, error) {
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	amountInMax, err := MaximumAmountIn(quotedAmountIn, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	return r.client.BuildContractTx(privKey, "swapTokensForExactETH", RouterV2Abi, &r.Address, opts,
+		amountOut, amountInMax, path, params.recipient(opts.From), params.deadline())
+}
+
+// transactOpts copy opts so that the caller's opts are not modified, From is set from the private key
+func transactOpts(privKey string, opts *bind.TransactOpts) (*bind.TransactOpts, error) {
+	pKey, err := crypto.HexToECDSA(privKey)
+	if err != nil {
+		return nil, errors.WithMessage(err, "hex private key to ECDSA key: ")
+	}
+	copied := bind.TransactOpts{}
+	if opts != nil {
+		copied = *opts
+	}
+	copied.From = crypto.PubkeyToAddress(pKey.PublicKey)
+	return &copied, nil
+}
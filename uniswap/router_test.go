@@ -0,0 +1,77 @@
+package uniswap
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterSelectors(t *testing.T) {
+	assert.Equal(t, "04e45aaf", hex.EncodeToString(swapRouter02Abi.Methods["exactInputSingle"].ID))
+	assert.Equal(t, "b858183f", hex.EncodeToString(swapRouter02Abi.Methods["exactInput"].ID))
+	assert.Equal(t, "5ae401dc", hex.EncodeToString(swapRouter02Abi.Methods["multicall"].ID))
+
+	_, err := swapRouter02Abi.Pack("exactInputSingle", ExactInputSingleParams{
+		Fee: big.NewInt(3000), AmountIn: big.NewInt(1), AmountOutMinimum: big.NewInt(1), SqrtPriceLimitX96: new(big.Int),
+	})
+	assert.Equal(t, nil, err)
+
+	routerV2Abi := mustParseAbi(RouterV2Abi)
+	assert.Equal(t, "38ed1739", hex.EncodeToString(routerV2Abi.Methods["swapExactTokensForTokens"].ID))
+	assert.Equal(t, "7ff36ab5", hex.EncodeToString(routerV2Abi.Methods["swapExactETHForTokens"].ID))
+
+	universalRouterAbi := mustParseAbi(UniversalRouterAbi)
+	assert.Equal(t, "3593564c", hex.EncodeToString(universalRouterAbi.Methods["execute"].ID))
+}
+
+func TestRoutePlanner(t *testing.T) {
+	weth := common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	dai := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	path, _ := EncodePath([]common.Address{weth, dai}, []int{3000})
+	amountIn := big.NewInt(1000)
+
+	planner := NewRoutePlanner()
+	if err := planner.WrapETH(RecipientAddressThis, amountIn); err != nil {
+		t.Fatal(err)
+	}
+	if err := planner.V3SwapExactIn(RecipientMsgSender, amountIn, big.NewInt(990), path, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := planner.Sweep(dai, RecipientMsgSender, big.NewInt(0)); err != nil {
+		t.Fatal(err)
+	}
+	planner.AddCommand(CommandPayPortion, []byte{}, true)
+	assert.Equal(t, []byte{CommandWrapETH, CommandV3SwapExactIn, CommandSweep, CommandPayPortion | CommandFlagAllowRevert}, planner.Commands())
+	assert.Equal(t, 4, len(planner.Inputs()))
+
+	values, err := v3SwapArguments.Unpack(planner.Inputs()[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, RecipientMsgSender, values[0])
+	assert.Equal(t, "990", values[2].(*big.Int).String())
+	assert.Equal(t, path, values[3])
+	assert.Equal(t, false, values[4])
+
+	permit := PermitSingle{
+		Details:     PermitDetails{Token: weth, Amount: amountIn, Expiration: big.NewInt(1), Nonce: big.NewInt(0)},
+		Spender:     common.HexToAddress(UniversalRouterAddr),
+		SigDeadline: big.NewInt(1),
+	}
+	assert.Equal(t, nil, planner.Permit2Permit(permit, make([]byte, 65)))
+}
+
+func TestSwapParams(t *testing.T) {
+	from := common.HexToAddress("0x431beE0E54b49105964E11b9035A198A1D4735AD")
+	params := SwapParams{}
+	assert.Equal(t, from, params.recipient(from))
+	deadline := params.deadline().Int64()
+	assert.InDelta(t, time.Now().Add(DefaultSwapDeadline).Unix(), deadline, 5)
+
+	params.Deadline = time.Unix(1700000000, 0)
+	assert.Equal(t, int64(1700000000), params.deadline().Int64())
+}
@@ -0,0 +1,33 @@
+package uniswap
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// checkBps return ErrBpsOutOfRange unless 0 <= bps < BpsDenominator
+func checkBps(name string, bps int) error {
+	if bps < 0 || bps >= BpsDenominator {
+		return errors.Wrapf(ErrBpsOutOfRange, "%s %d", name, bps)
+	}
+	return nil
+}
+
+// MinimumAmountOut returns the minimum output amount accepting slippageBps basis points of slippage,
+// use it as amountOutMin of router calls
+func MinimumAmountOut(amountOut *big.Int, slippageBps int) (*big.Int, error) {
+	if err := checkBps("slippage", slippageBps); err != nil {
+		return nil, err
+	}
+	return mulDiv(amountOut, big.NewInt(int64(BpsDenominator-slippageBps)), big.NewInt(BpsDenominator)), nil
+}
+
+// MaximumAmountIn returns the maximum input amount accepting slippageBps basis points of slippage,
+// use it as amountInMax of router calls
+func MaximumAmountIn(amountIn *big.Int, slippageBps int) (*big.Int, error) {
+	if err := checkBps("slippage", slippageBps); err != nil {
+		return nil, err
+	}
+	return mulDivRoundingUp(amountIn, big.NewInt(int64(BpsDenominator+slippageBps)), big.NewInt(BpsDenominator)), nil
+}
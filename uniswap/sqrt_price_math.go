@@ -0,0 +1,105 @@
+package uniswap
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// GetAmount0Delta calculate amount0 delta between two prices, same as SqrtPriceMath.getAmount0Delta
+// amount0 = liquidity / sqrt(lower) - liquidity / sqrt(upper)
+func GetAmount0Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) *big.Int {
+	if sqrtRatioAX96.Cmp(sqrtRatioBX96) > 0 {
+		sqrtRatioAX96, sqrtRatioBX96 = sqrtRatioBX96, sqrtRatioAX96
+	}
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	numerator2 := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+
+	if roundUp {
+		return divRoundingUp(mulDivRoundingUp(numerator1, numerator2, sqrtRatioBX96), sqrtRatioAX96)
+	}
+	amount0 := mulDiv(numerator1, numerator2, sqrtRatioBX96)
+	return amount0.Div(amount0, sqrtRatioAX96)
+}
+
+// GetAmount1Delta calculate amount1 delta between two prices, same as SqrtPriceMath.getAmount1Delta
+// amount1 = liquidity * (sqrt(upper) - sqrt(lower))
+func GetAmount1Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) *big.Int {
+	if sqrtRatioAX96.Cmp(sqrtRatioBX96) > 0 {
+		sqrtRatioAX96, sqrtRatioBX96 = sqrtRatioBX96, sqrtRatioAX96
+	}
+	diff := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+
+	if roundUp {
+		return mulDivRoundingUp(liquidity, diff, Q96)
+	}
+	return mulDiv(liquidity, diff, Q96)
+}
+
+// GetNextSqrtPriceFromInput calculate the next sqrt price given an input amount of token0 or token1,
+// same as SqrtPriceMath.getNextSqrtPriceFromInput
+func GetNextSqrtPriceFromInput(sqrtPX96, liquidity, amountIn *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPX96.Sign() <= 0 || liquidity.Sign() <= 0 {
+		return nil, errors.New("sqrt price and liquidity must be positive")
+	}
+	if zeroForOne {
+		return getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountIn, true)
+	}
+	return getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountIn, true)
+}
+
+// GetNextSqrtPriceFromOutput calculate the next sqrt price given an output amount of token0 or token1,
+// same as SqrtPriceMath.getNextSqrtPriceFromOutput
+func GetNextSqrtPriceFromOutput(sqrtPX96, liquidity, amountOut *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPX96.Sign() <= 0 || liquidity.Sign() <= 0 {
+		return nil, errors.New("sqrt price and liquidity must be positive")
+	}
+	if zeroForOne {
+		return getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountOut, false)
+	}
+	return getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountOut, false)
+}
+
+func getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	if amount.Sign() == 0 {
+		return new(big.Int).Set(sqrtPX96), nil
+	}
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	product := new(big.Int).Mul(amount, sqrtPX96)
+
+	if add {
+		denominator := new(big.Int).Add(numerator1, product)
+		// the contract falls back to a less precise formula when the product overflows uint256
+		if product.Cmp(maxUint256) <= 0 && denominator.Cmp(maxUint256) <= 0 {
+			return mulDivRoundingUp(numerator1, sqrtPX96, denominator), nil
+		}
+		return divRoundingUp(numerator1, new(big.Int).Add(new(big.Int).Div(numerator1, sqrtPX96), amount)), nil
+	}
+
+	if product.Cmp(maxUint256) > 0 || numerator1.Cmp(product) <= 0 {
+		return nil, errors.New("insufficient liquidity for output amount")
+	}
+	denominator := new(big.Int).Sub(numerator1, product)
+	sqrtQX96 := mulDivRoundingUp(numerator1, sqrtPX96, denominator)
+	if sqrtQX96.Cmp(maxUint160) > 0 {
+		return nil, errors.New("sqrt price overflows uint160")
+	}
+	return sqrtQX96, nil
+}
+
+func getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	if add {
+		quotient := mulDiv(amount, Q96, liquidity)
+		sqrtQX96 := quotient.Add(quotient, sqrtPX96)
+		if sqrtQX96.Cmp(maxUint160) > 0 {
+			return nil, errors.New("sqrt price overflows uint160")
+		}
+		return sqrtQX96, nil
+	}
+
+	quotient := mulDivRoundingUp(amount, Q96, liquidity)
+	if sqrtPX96.Cmp(quotient) <= 0 {
+		return nil, errors.New("insufficient liquidity for output amount")
+	}
+	return quotient.Sub(sqrtPX96, quotient), nil
+}
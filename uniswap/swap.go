@@ -0,0 +1,231 @@
+package uniswap
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var ErrTickDataExhausted = errors.New("swap crosses ticks outside the loaded tick data")
+
+// Tick is an initialized tick of uniswap v3 pool
+type Tick struct {
+	Index          int
+	LiquidityNet   *big.Int
+	LiquidityGross *big.Int
+}
+
+// PoolState is the uniswap v3 pool state needed to simulate swaps offline
+type PoolState struct {
+	SqrtPriceX96 *big.Int
+	Liquidity    *big.Int
+	Tick         int
+	Fee          int
+	TickSpacing  int
+	// FeeGrowthGlobal0X128 and FeeGrowthGlobal1X128 are the fee growth per unit of liquidity, they are
+	// not used by swap simulation
+	FeeGrowthGlobal0X128 *big.Int
+	FeeGrowthGlobal1X128 *big.Int
+	// Ticks are the initialized ticks of the pool sorted by index
+	Ticks []Tick
+	// TickLowerBound and TickUpperBound limit the tick range covered by Ticks, swaps that leave
+	// this range return ErrTickDataExhausted. Both zero means Ticks covers the whole tick range.
+	TickLowerBound int
+	TickUpperBound int
+}
+
+// SwapResult is the result of a simulated swap
+type SwapResult struct {
+	// Amount0 and Amount1 are the balance deltas of the pool, positive means the pool received tokens
+	Amount0   *big.Int
+	Amount1   *big.Int
+	AmountIn  *big.Int
+	AmountOut *big.Int
+	FeeAmount *big.Int
+	// SqrtPriceX96, Liquidity and Tick are the pool state after the swap
+	SqrtPriceX96 *big.Int
+	Liquidity    *big.Int
+	Tick         int
+	TicksCrossed []int
+}
+
+// checkPoolPrice return ErrSqrtPriceOutOfRange when the pool price is outside the tick range, an uninitialized
+// pool has a zero price
+func checkPoolPrice(sqrtPriceX96 *big.Int) error {
+	if sqrtPriceX96 == nil || sqrtPriceX96.Cmp(MinSqrtRatio) < 0 || sqrtPriceX96.Cmp(MaxSqrtRatio) >= 0 {
+		return errors.Wrapf(ErrSqrtPriceOutOfRange, "pool sqrt price %v, the pool may not be initialized", sqrtPriceX96)
+	}
+	return nil
+}
+
+// SimulateSwap simulate UniswapV3Pool.swap offline over the given pool state, the state is not modified.
+// amountSpecified is positive for exact input and negative for exact output, sqrtPriceLimitX96 can be nil
+// for no price limit.
+func SimulateSwap(state *PoolState, zeroForOne bool, amountSpecified, sqrtPriceLimitX96 *big.Int) (result *SwapResult, err error) {
+	if amountSpecified.Sign() == 0 {
+		err = errors.New("amount specified is zero")
+		return
+	}
+	if state.Liquidity.Sign() < 0 || state.TickSpacing <= 0 {
+		err = errors.New("invalid pool state")
+		return
+	}
+	if err = checkPoolPrice(state.SqrtPriceX96); err != nil {
+		return
+	}
+	if sqrtPriceLimitX96 == nil {
+		if zeroForOne {
+			sqrtPriceLimitX96 = new(big.Int).Add(MinSqrtRatio, big.NewInt(1))
+		} else {
+			sqrtPriceLimitX96 = new(big.Int).Sub(MaxSqrtRatio, big.NewInt(1))
+		}
+	}
+	if zeroForOne {
+		if sqrtPriceLimitX96.Cmp(state.SqrtPriceX96) >= 0 || sqrtPriceLimitX96.Cmp(MinSqrtRatio) <= 0 {
+			err = errors.New("sqrt price limit out of range")
+			return
+		}
+	} else {
+		if sqrtPriceLimitX96.Cmp(state.SqrtPriceX96) <= 0 || sqrtPriceLimitX96.Cmp(MaxSqrtRatio) >= 0 {
+			err = errors.New("sqrt price limit out of range")
+			return
+		}
+	}
+
+	exactInput := amountSpecified.Sign() > 0
+	bounded := state.TickLowerBound != 0 || state.TickUpperBound != 0
+	var (
+		amountSpecifiedRemaining = new(big.Int).Set(amountSpecified)
+		amountCalculated         = new(big.Int)
+		sqrtPriceX96             = new(big.Int).Set(state.SqrtPriceX96)
+		tick                     = state.Tick
+		liquidity                = new(big.Int).Set(state.Liquidity)
+		feeAmount                = new(big.Int)
+		ticksCrossed             = make([]int, 0)
+	)
+
+	for amountSpecifiedRemaining.Sign() != 0 && sqrtPriceX96.Cmp(sqrtPriceLimitX96) != 0 {
+		sqrtPriceStartX96 := sqrtPriceX96
+		tickNext, initialized := nextInitializedTickWithinOneWord(state.Ticks, tick, state.TickSpacing, zeroForOne)
+		if bounded && (tickNext < state.TickLowerBound || tickNext > state.TickUpperBound) {
+			err = errors.Wrapf(ErrTickDataExhausted, "next tick %d", tickNext)
+			return
+		}
+		if tickNext < MinTick {
+			tickNext = MinTick
+		} else if tickNext > MaxTick {
+			tickNext = MaxTick
+		}
+		sqrtPriceNextX96, _ := GetSqrtRatioAtTick(tickNext)
+
+		sqrtPriceTargetX96 := sqrtPriceNextX96
+		if (zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceLimitX96) < 0) || (!zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceLimitX96) > 0) {
+			sqrtPriceTargetX96 = sqrtPriceLimitX96
+		}
+
+		var amountIn, amountOut, stepFeeAmount *big.Int
+		sqrtPriceX96, amountIn, amountOut, stepFeeAmount, err = ComputeSwapStep(sqrtPriceX96, sqrtPriceTargetX96, liquidity, amountSpecifiedRemaining, state.Fee)
+		if err != nil {
+			err = errors.WithMessagef(err, "compute swap step at tick %d", tick)
+			return
+		}
+		feeAmount.Add(feeAmount, stepFeeAmount)
+
+		if exactInput {
+			amountSpecifiedRemaining.Sub(amountSpecifiedRemaining, new(big.Int).Add(amountIn, stepFeeAmount))
+			amountCalculated.Sub(amountCalculated, amountOut)
+		} else {
+			amountSpecifiedRemaining.Add(amountSpecifiedRemaining, amountOut)
+			amountCalculated.Add(amountCalculated, new(big.Int).Add(amountIn, stepFeeAmount))
+		}
+
+		if sqrtPriceX96.Cmp(sqrtPriceNextX96) == 0 {
+			// the price reached the next tick, cross it if it is initialized
+			if initialized {
+				liquidityNet := tickLiquidityNet(state.Ticks, tickNext)
+				if zeroForOne {
+					liquidityNet = new(big.Int).Neg(liquidityNet)
+				}
+				liquidity.Add(liquidity, liquidityNet)
+				if liquidity.Sign() < 0 {
+					err = errors.Errorf("negative liquidity after crossing tick %d", tickNext)
+					return
+				}
+				ticksCrossed = append(ticksCrossed, tickNext)
+			}
+			if zeroForOne {
+				tick = tickNext - 1
+			} else {
+				tick = tickNext
+			}
+		} else if sqrtPriceX96.Cmp(sqrtPriceStartX96) != 0 {
+			tick, err = GetTickAtSqrtRatio(sqrtPriceX96)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	result = &SwapResult{
+		FeeAmount:    feeAmount,
+		SqrtPriceX96: sqrtPriceX96,
+		Liquidity:    liquidity,
+		Tick:         tick,
+		TicksCrossed: ticksCrossed,
+	}
+	amountUsed := new(big.Int).Sub(amountSpecified, amountSpecifiedRemaining)
+	if zeroForOne == exactInput {
+		result.Amount0, result.Amount1 = amountUsed, amountCalculated
+	} else {
+		result.Amount0, result.Amount1 = amountCalculated, amountUsed
+	}
+	if zeroForOne {
+		result.AmountIn, result.AmountOut = new(big.Int).Set(result.Amount0), new(big.Int).Neg(result.Amount1)
+	} else {
+		result.AmountIn, result.AmountOut = new(big.Int).Set(result.Amount1), new(big.Int).Neg(result.Amount0)
+	}
+	return
+}
+
+// nextInitializedTickWithinOneWord find the next initialized tick within the same tick bitmap word,
+// same as TickBitmap.nextInitializedTickWithinOneWord but over a sorted tick list
+func nextInitializedTickWithinOneWord(ticks []Tick, tick, tickSpacing int, lte bool) (next int, initialized bool) {
+	compressed := floorDiv(tick, tickSpacing)
+	if lte {
+		bitPos := compressed & 0xff
+		lowest := (compressed - bitPos) * tickSpacing
+		// the greatest initialized tick <= tick
+		i := sort.Search(len(ticks), func(i int) bool { return ticks[i].Index > compressed*tickSpacing }) - 1
+		if i >= 0 && ticks[i].Index >= lowest {
+			return ticks[i].Index, true
+		}
+		return lowest, false
+	}
+
+	compressed++
+	bitPos := compressed & 0xff
+	highest := (compressed + 0xff - bitPos) * tickSpacing
+	// the smallest initialized tick > tick
+	i := sort.Search(len(ticks), func(i int) bool { return ticks[i].Index >= compressed*tickSpacing })
+	if i < len(ticks) && ticks[i].Index <= highest {
+		return ticks[i].Index, true
+	}
+	return highest, false
+}
+
+func tickLiquidityNet(ticks []Tick, index int) *big.Int {
+	i := sort.Search(len(ticks), func(i int) bool { return ticks[i].Index >= index })
+	if i < len(ticks) && ticks[i].Index == index && ticks[i].LiquidityNet != nil {
+		return ticks[i].LiquidityNet
+	}
+	return new(big.Int)
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
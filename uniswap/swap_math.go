@@ -0,0 +1,78 @@
+package uniswap
+
+import (
+	"math/big"
+)
+
+// FeeDenominator is the denominator of uniswap v3 fees, fees are expressed in hundredths of a bip
+const FeeDenominator = 1000000
+
+// ComputeSwapStep calculate the result of swapping some amount in or out within a single price range,
+// same as SwapMath.computeSwapStep. amountRemaining is positive for exact input and negative for exact output.
+func ComputeSwapStep(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, amountRemaining *big.Int, feePips int) (sqrtRatioNextX96, amountIn, amountOut, feeAmount *big.Int, err error) {
+	zeroForOne := sqrtRatioCurrentX96.Cmp(sqrtRatioTargetX96) >= 0
+	exactIn := amountRemaining.Sign() >= 0
+	fee := big.NewInt(int64(feePips))
+	feeComplement := big.NewInt(int64(FeeDenominator - feePips))
+
+	if exactIn {
+		amountRemainingLessFee := mulDiv(amountRemaining, feeComplement, big.NewInt(FeeDenominator))
+		if zeroForOne {
+			amountIn = GetAmount0Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, true)
+		} else {
+			amountIn = GetAmount1Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, true)
+		}
+		if amountRemainingLessFee.Cmp(amountIn) >= 0 {
+			sqrtRatioNextX96 = new(big.Int).Set(sqrtRatioTargetX96)
+		} else {
+			sqrtRatioNextX96, err = GetNextSqrtPriceFromInput(sqrtRatioCurrentX96, liquidity, amountRemainingLessFee, zeroForOne)
+			if err != nil {
+				return
+			}
+		}
+	} else {
+		if zeroForOne {
+			amountOut = GetAmount1Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, false)
+		} else {
+			amountOut = GetAmount0Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, false)
+		}
+		if new(big.Int).Neg(amountRemaining).Cmp(amountOut) >= 0 {
+			sqrtRatioNextX96 = new(big.Int).Set(sqrtRatioTargetX96)
+		} else {
+			sqrtRatioNextX96, err = GetNextSqrtPriceFromOutput(sqrtRatioCurrentX96, liquidity, new(big.Int).Neg(amountRemaining), zeroForOne)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	max := sqrtRatioTargetX96.Cmp(sqrtRatioNextX96) == 0
+	if zeroForOne {
+		if !(max && exactIn) {
+			amountIn = GetAmount0Delta(sqrtRatioNextX96, sqrtRatioCurrentX96, liquidity, true)
+		}
+		if !(max && !exactIn) {
+			amountOut = GetAmount1Delta(sqrtRatioNextX96, sqrtRatioCurrentX96, liquidity, false)
+		}
+	} else {
+		if !(max && exactIn) {
+			amountIn = GetAmount1Delta(sqrtRatioCurrentX96, sqrtRatioNextX96, liquidity, true)
+		}
+		if !(max && !exactIn) {
+			amountOut = GetAmount0Delta(sqrtRatioCurrentX96, sqrtRatioNextX96, liquidity, false)
+		}
+	}
+
+	// cap the output amount to not exceed the remaining output amount
+	if !exactIn && amountOut.Cmp(new(big.Int).Neg(amountRemaining)) > 0 {
+		amountOut = new(big.Int).Neg(amountRemaining)
+	}
+
+	if exactIn && sqrtRatioNextX96.Cmp(sqrtRatioTargetX96) != 0 {
+		// we didn't reach the target, so take the remainder of the maximum input as fee
+		feeAmount = new(big.Int).Sub(amountRemaining, amountIn)
+	} else {
+		feeAmount = mulDivRoundingUp(amountIn, fee, feeComplement)
+	}
+	return
+}
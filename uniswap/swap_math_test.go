@@ -0,0 +1,54 @@
+package uniswap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePriceSqrt(reserve1, reserve0 int64) *big.Int {
+	ratio := new(big.Int).Lsh(big.NewInt(reserve1), 192)
+	ratio.Div(ratio, big.NewInt(reserve0))
+	return ratio.Sqrt(ratio)
+}
+
+func TestComputeSwapStep(t *testing.T) {
+	// exact amount in that gets capped at price target in one for zero
+	liquidity, _ := new(big.Int).SetString("2000000000000000000", 10)
+	amount, _ := new(big.Int).SetString("1000000000000000000", 10)
+	priceTarget := encodePriceSqrt(101, 100)
+	sqrtQ, amountIn, amountOut, feeAmount, err := ComputeSwapStep(encodePriceSqrt(1, 1), priceTarget, liquidity, amount, 600)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "9975124224178055", amountIn.String())
+	assert.Equal(t, "5988667735148", feeAmount.String())
+	assert.Equal(t, "9925619580021728", amountOut.String())
+	assert.Equal(t, priceTarget.String(), sqrtQ.String())
+
+	// amount out is capped at the desired amount out
+	current, _ := new(big.Int).SetString("417332158212080721273783715441582", 10)
+	target, _ := new(big.Int).SetString("1452870262520218020823638996", 10)
+	liquidity, _ = new(big.Int).SetString("159344665391607089467575320103", 10)
+	sqrtQ, amountIn, amountOut, feeAmount, err = ComputeSwapStep(current, target, liquidity, big.NewInt(-1), 1)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "1", amountIn.String())
+	assert.Equal(t, "1", feeAmount.String())
+	assert.Equal(t, "1", amountOut.String())
+	assert.Equal(t, "417332158212080721273783715441581", sqrtQ.String())
+
+	// entire input amount taken as fee
+	target, _ = new(big.Int).SetString("79887613182836312", 10)
+	liquidity, _ = new(big.Int).SetString("1985041575832132834610021537970", 10)
+	sqrtQ, amountIn, amountOut, feeAmount, err = ComputeSwapStep(big.NewInt(2413), target, liquidity, big.NewInt(10), 1872)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "0", amountIn.String())
+	assert.Equal(t, "10", feeAmount.String())
+	assert.Equal(t, "0", amountOut.String())
+	assert.Equal(t, "2413", sqrtQ.String())
+}
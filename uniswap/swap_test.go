@@ -0,0 +1,95 @@
+package uniswap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPoolState() *PoolState {
+	liquidity, _ := new(big.Int).SetString("1000000000000000000", 10)
+	return &PoolState{
+		SqrtPriceX96: new(big.Int).Set(Q96),
+		Liquidity:    new(big.Int).Add(liquidity, liquidity),
+		Tick:         0,
+		Fee:          3000,
+		TickSpacing:  60,
+		Ticks: []Tick{
+			{Index: -887220, LiquidityNet: new(big.Int).Set(liquidity)},
+			{Index: -120, LiquidityNet: new(big.Int).Set(liquidity)},
+			{Index: 120, LiquidityNet: new(big.Int).Neg(liquidity)},
+			{Index: 887220, LiquidityNet: new(big.Int).Neg(liquidity)},
+		},
+	}
+}
+
+func TestSimulateSwap(t *testing.T) {
+	state := testPoolState()
+	amount, _ := new(big.Int).SetString("10000000000000000", 10)
+
+	// exact input within the current range
+	result, err := SimulateSwap(state, true, amount, nil)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, amount.String(), result.AmountIn.String())
+	assert.Equal(t, amount.String(), result.Amount0.String())
+	assert.Equal(t, 1, result.AmountOut.Sign())
+	assert.Equal(t, -1, result.Amount1.Sign())
+	assert.Equal(t, 0, len(result.TicksCrossed))
+	assert.Equal(t, -1, result.SqrtPriceX96.Cmp(Q96))
+	assert.Equal(t, Q96.String(), state.SqrtPriceX96.String())
+
+	// exact output of the same amount needs no more input
+	exactOut, err := SimulateSwap(state, true, new(big.Int).Neg(result.AmountOut), nil)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, result.AmountOut.String(), exactOut.AmountOut.String())
+	assert.LessOrEqual(t, exactOut.AmountIn.Cmp(amount), 0)
+
+	// large swap crosses the -120 tick and loses half of the liquidity
+	amount, _ = new(big.Int).SetString("100000000000000000", 10)
+	result, err = SimulateSwap(state, true, amount, nil)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []int{-120}, result.TicksCrossed)
+	assert.Equal(t, "1000000000000000000", result.Liquidity.String())
+	assert.Less(t, result.Tick, -120)
+
+	// price limit stops the swap early
+	limit, _ := GetSqrtRatioAtTick(-60)
+	result, err = SimulateSwap(state, true, amount, limit)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, limit.String(), result.SqrtPriceX96.String())
+	assert.Equal(t, -1, result.AmountIn.Cmp(amount))
+}
+
+func TestSimulateSwapTickDataExhausted(t *testing.T) {
+	state := testPoolState()
+	state.Ticks = state.Ticks[1:3]
+	state.TickLowerBound, state.TickUpperBound = -256*60, 256*60-1
+
+	amount, _ := new(big.Int).SetString("10000000000000000000", 10)
+	_, err := SimulateSwap(state, true, amount, nil)
+	assert.ErrorIs(t, err, ErrTickDataExhausted)
+}
+
+func TestSimulateSwapUninitializedPool(t *testing.T) {
+	state := testPoolState()
+	state.SqrtPriceX96 = new(big.Int)
+
+	amount, _ := new(big.Int).SetString("10000000000000000", 10)
+	for _, zeroForOne := range []bool{true, false} {
+		_, err := SimulateSwap(state, zeroForOne, amount, nil)
+		assert.ErrorIs(t, err, ErrSqrtPriceOutOfRange)
+	}
+
+	state.SqrtPriceX96 = new(big.Int).Set(MaxSqrtRatio)
+	_, err := SimulateSwap(state, true, amount, nil)
+	assert.ErrorIs(t, err, ErrSqrtPriceOutOfRange)
+}
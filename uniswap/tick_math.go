@@ -0,0 +1,108 @@
+package uniswap
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	MinTick = -887272
+	MaxTick = 887272
+)
+
+var (
+	MinSqrtRatio, _ = new(big.Int).SetString("4295128739", 10)
+	MaxSqrtRatio, _ = new(big.Int).SetString("1461446703485210103287273052203988822378723970342", 10)
+
+	ErrTickOutOfRange      = errors.New("tick out of range")
+	ErrSqrtPriceOutOfRange = errors.New("sqrt price out of range")
+
+	// sqrtRatioTickMultipliers are 1/sqrt(1.0001)^(2^i) in Q128.128 for i >= 1
+	sqrtRatioTickMultipliers = parseHexInts(
+		"fff97272373d413259a46990580e213a",
+		"fff2e50f5f656932ef12357cf3c7fdcc",
+		"ffe5caca7e10e4e61c3624eaa0941cd0",
+		"ffcb9843d60f6159c9db58835c926644",
+		"ff973b41fa98c081472e6896dfb254c0",
+		"ff2ea16466c96a3843ec78b326b52861",
+		"fe5dee046a99a2a811c461f1969c3053",
+		"fcbe86c7900a88aedcffc83b479aa3a4",
+		"f987a7253ac413176f2b074cf7815e54",
+		"f3392b0822b70005940c7a398e4b70f3",
+		"e7159475a2c29b7443b29c7fa6e889d9",
+		"d097f3bdfd2022b8845ad8f792aa5825",
+		"a9f746462d870fdf8a65dc1f90e061e5",
+		"70d869a156d2a1b890bb3df62baf32f7",
+		"31be135f97d08fd981231505542fcfa6",
+		"9aa508b5b7a84e1c677de54f3e99bc9",
+		"5d6af8dedb81196699c329225ee604",
+		"2216e584f5fa1ea926041bedfe98",
+		"48a170391f7dc42444e8fa2",
+	)
+)
+
+func parseHexInts(hexes ...string) []*big.Int {
+	ints := make([]*big.Int, len(hexes))
+	for i, h := range hexes {
+		ints[i], _ = new(big.Int).SetString(h, 16)
+	}
+	return ints
+}
+
+// GetSqrtRatioAtTick calculate sqrt(1.0001^tick) * 2^96, same as TickMath.getSqrtRatioAtTick
+func GetSqrtRatioAtTick(tick int) (sqrtPriceX96 *big.Int, err error) {
+	if tick < MinTick || tick > MaxTick {
+		err = errors.Wrapf(ErrTickOutOfRange, "tick %d", tick)
+		return
+	}
+	absTick := tick
+	if absTick < 0 {
+		absTick = -absTick
+	}
+
+	var ratio *big.Int
+	if absTick&0x1 != 0 {
+		ratio, _ = new(big.Int).SetString("fffcb933bd6fad37aa2d162d1a594001", 16)
+	} else {
+		ratio = new(big.Int).Set(Q128)
+	}
+	for i, multiplier := range sqrtRatioTickMultipliers {
+		if absTick&(0x2<<i) != 0 {
+			ratio.Mul(ratio, multiplier)
+			ratio.Rsh(ratio, 128)
+		}
+	}
+	if tick > 0 {
+		ratio.Div(maxUint256, ratio)
+	}
+
+	// round up so that getTickAtSqrtRatio of the output price is always consistent
+	sqrtPriceX96, remainder := new(big.Int).QuoRem(ratio, new(big.Int).Lsh(big.NewInt(1), 32), new(big.Int))
+	if remainder.Sign() > 0 {
+		sqrtPriceX96.Add(sqrtPriceX96, big.NewInt(1))
+	}
+	return
+}
+
+// GetTickAtSqrtRatio calculate the greatest tick value such that GetSqrtRatioAtTick(tick) <= sqrtPriceX96,
+// same as TickMath.getTickAtSqrtRatio
+func GetTickAtSqrtRatio(sqrtPriceX96 *big.Int) (tick int, err error) {
+	if sqrtPriceX96.Cmp(MinSqrtRatio) < 0 || sqrtPriceX96.Cmp(MaxSqrtRatio) >= 0 {
+		err = errors.Wrapf(ErrSqrtPriceOutOfRange, "sqrt price %s", sqrtPriceX96)
+		return
+	}
+
+	// binary search over the ticks, GetSqrtRatioAtTick is monotonic
+	low, high := MinTick, MaxTick
+	for low < high {
+		mid := low + (high-low+1)/2
+		ratio, _ := GetSqrtRatioAtTick(mid)
+		if ratio.Cmp(sqrtPriceX96) <= 0 {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return low, nil
+}
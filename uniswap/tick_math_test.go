@@ -0,0 +1,48 @@
+package uniswap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSqrtRatioAtTick(t *testing.T) {
+	ratio, err := GetSqrtRatioAtTick(MinTick)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, MinSqrtRatio.String(), ratio.String())
+
+	ratio, _ = GetSqrtRatioAtTick(MaxTick)
+	assert.Equal(t, MaxSqrtRatio.String(), ratio.String())
+
+	ratio, _ = GetSqrtRatioAtTick(0)
+	assert.Equal(t, Q96.String(), ratio.String())
+
+	ratio, _ = GetSqrtRatioAtTick(1)
+	assert.Equal(t, "79232123823359799118286999568", ratio.String())
+
+	ratio, _ = GetSqrtRatioAtTick(-1)
+	assert.Equal(t, "79224201403219477170569942574", ratio.String())
+
+	_, err = GetSqrtRatioAtTick(MaxTick + 1)
+	assert.ErrorIs(t, err, ErrTickOutOfRange)
+}
+
+func TestGetTickAtSqrtRatio(t *testing.T) {
+	tick, err := GetTickAtSqrtRatio(MinSqrtRatio)
+	if !assert.Equal(t, nil, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, MinTick, tick)
+
+	tick, _ = GetTickAtSqrtRatio(new(big.Int).Sub(MaxSqrtRatio, big.NewInt(1)))
+	assert.Equal(t, MaxTick-1, tick)
+
+	tick, _ = GetTickAtSqrtRatio(new(big.Int).Sub(Q96, big.NewInt(1)))
+	assert.Equal(t, -1, tick)
+
+	_, err = GetTickAtSqrtRatio(MaxSqrtRatio)
+	assert.ErrorIs(t, err, ErrSqrtPriceOutOfRange)
+}
@@ -0,0 +1,147 @@
+package uniswap
+
+import (
+	"math/big"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var ErrObservationTooOld = errors.New("observation cardinality too small for the twap window")
+
+// TWAP is the time weighted average of uniswap v3 pool over a window, same as OracleLibrary.consult
+type TWAP struct {
+	// Window is the length of the averaging window in seconds
+	Window                uint32
+	ArithmeticMeanTick    int
+	HarmonicMeanLiquidity *big.Int
+	// SqrtPriceX96 is the sqrt price at ArithmeticMeanTick
+	SqrtPriceX96 *big.Int
+}
+
+// Price convert the average price to decimal.Decimal, see SqrtPriceX96ToPrice for zeroForOne
+func (t *TWAP) Price(zeroForOne bool) decimal.Decimal {
+	return SqrtPriceX96ToPrice(t.SqrtPriceX96, zeroForOne)
+}
+
+// Observe call pool observe, returns the cumulative values at each of secondsAgos.
+// Reverted calls are reported with the observation window the pool can serve.
+func Observe(client *ethclient.Client, pool common.Address, opts *bind.CallOpts, secondsAgos []uint32) (tickCumulatives, secondsPerLiquidityCumulativeX128s []*big.Int, err error) {
+	callData, err := poolV3Abi.Pack("observe", secondsAgos)
+	if err != nil {
+		err = errors.Wrap(err, "pack observe")
+		return
+	}
+	results, err := TryAggregate(client, opts, false, []Multicall2Call{{Target: pool, CallData: callData}})
+	if err != nil {
+		return
+	}
+	if !results[0].Success {
+		err = observationWindowError(client, pool, opts)
+		return
+	}
+
+	values, err := poolV3Abi.Unpack("observe", results[0].ReturnData)
+	if err != nil {
+		err = errors.Wrap(err, "unpack observe")
+		return
+	}
+	return values[0].([]*big.Int), values[1].([]*big.Int), nil
+}
+
+// observationWindowError read the oldest observation of pool to explain why observe reverted
+func observationWindowError(client *ethclient.Client, pool common.Address, opts *bind.CallOpts) error {
+	callData, err := poolV3Abi.Pack("slot0")
+	if err != nil {
+		return errors.Wrap(err, "pack slot0")
+	}
+	results, err := TryAggregate(client, opts, true, []Multicall2Call{{Target: pool, CallData: callData}})
+	if err != nil {
+		return errors.WithMessage(err, "observe reverted")
+	}
+	slot0, err := poolV3Abi.Unpack("slot0", results[0].ReturnData)
+	if err != nil {
+		return errors.Wrap(err, "unpack slot0")
+	}
+	index, cardinality := slot0[2].(uint16), slot0[3].(uint16)
+	if cardinality <= 1 {
+		return errors.Wrapf(ErrObservationTooOld, "pool %s keeps %d observation, increase it with increaseObservationCardinalityNext", pool, cardinality)
+	}
+
+	// the oldest observation is the next one in the ring buffer, or the first one if the buffer is not full yet
+	calls := make([]Multicall2Call, 0, 2)
+	for _, i := range []uint16{(index + 1) % cardinality, 0} {
+		callData, err = poolV3Abi.Pack("observations", big.NewInt(int64(i)))
+		if err != nil {
+			return errors.Wrap(err, "pack observations")
+		}
+		calls = append(calls, Multicall2Call{Target: pool, CallData: callData})
+	}
+	results, err = TryAggregate(client, opts, true, calls)
+	if err != nil {
+		return errors.WithMessage(err, "observe reverted")
+	}
+	oldest, err := poolV3Abi.Unpack("observations", results[0].ReturnData)
+	if err != nil {
+		return errors.Wrap(err, "unpack observations")
+	}
+	if !oldest[3].(bool) {
+		oldest, err = poolV3Abi.Unpack("observations", results[1].ReturnData)
+		if err != nil {
+			return errors.Wrap(err, "unpack observations")
+		}
+	}
+	return errors.Wrapf(ErrObservationTooOld, "pool %s oldest observation at timestamp %d, cardinality %d", pool, oldest[0].(uint32), cardinality)
+}
+
+// ConsultTWAP read the time weighted average tick and liquidity of pool over the last window seconds
+func ConsultTWAP(client *ethclient.Client, pool common.Address, opts *bind.CallOpts, window uint32) (*TWAP, error) {
+	if window == 0 {
+		return nil, errors.New("twap window is zero")
+	}
+	tickCumulatives, secondsPerLiquidityCumulativeX128s, err := Observe(client, pool, opts, []uint32{window, 0})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "observe %d seconds", window)
+	}
+	return ComputeTWAP(window, tickCumulatives, secondsPerLiquidityCumulativeX128s)
+}
+
+// ComputeTWAP calculate the arithmetic mean tick and harmonic mean liquidity from the observe results of
+// secondsAgos [window, 0], same as OracleLibrary.consult
+func ComputeTWAP(window uint32, tickCumulatives, secondsPerLiquidityCumulativeX128s []*big.Int) (twap *TWAP, err error) {
+	if window == 0 {
+		err = errors.New("twap window is zero")
+		return
+	}
+	if len(tickCumulatives) != 2 || len(secondsPerLiquidityCumulativeX128s) != 2 {
+		err = errors.New("observe results must contain two observations")
+		return
+	}
+
+	seconds := big.NewInt(int64(window))
+	tickCumulativesDelta := new(big.Int).Sub(tickCumulatives[1], tickCumulatives[0])
+	// Quo truncates toward zero, round toward negative infinity like the library
+	meanTick, remainder := new(big.Int).QuoRem(tickCumulativesDelta, seconds, new(big.Int))
+	if tickCumulativesDelta.Sign() < 0 && remainder.Sign() != 0 {
+		meanTick.Sub(meanTick, big.NewInt(1))
+	}
+
+	twap = &TWAP{Window: window, ArithmeticMeanTick: int(meanTick.Int64())}
+	twap.SqrtPriceX96, err = GetSqrtRatioAtTick(twap.ArithmeticMeanTick)
+	if err != nil {
+		return nil, err
+	}
+
+	// seconds per liquidity wraps around uint160
+	secondsPerLiquidityDelta := new(big.Int).Sub(secondsPerLiquidityCumulativeX128s[1], secondsPerLiquidityCumulativeX128s[0])
+	secondsPerLiquidityDelta.And(secondsPerLiquidityDelta, maxUint160)
+	if secondsPerLiquidityDelta.Sign() == 0 {
+		return nil, errors.New("pool had no in range liquidity over the twap window")
+	}
+	secondsAgoX160 := new(big.Int).Mul(seconds, maxUint160)
+	twap.HarmonicMeanLiquidity = secondsAgoX160.Quo(secondsAgoX160, secondsPerLiquidityDelta.Lsh(secondsPerLiquidityDelta, 32))
+	return
+}
@@ -0,0 +1,44 @@
+package uniswap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeTWAP(t *testing.T) {
+	liquidity, _ := new(big.Int).SetString("1000000000000000000", 10)
+	start, _ := new(big.Int).SetString("123456789", 10)
+	// constant liquidity over the window, secondsPerLiquidity grows by window / liquidity in Q128
+	secondsPerLiquidityDelta := new(big.Int).Div(new(big.Int).Lsh(big.NewInt(60), 128), liquidity)
+
+	twap, err := ComputeTWAP(60,
+		[]*big.Int{big.NewInt(1000), big.NewInt(1000 - 601)},
+		[]*big.Int{start, new(big.Int).Add(start, secondsPerLiquidityDelta)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// -601 / 60 rounds toward negative infinity
+	assert.Equal(t, -11, twap.ArithmeticMeanTick)
+	assert.Equal(t, mustSqrtRatio(-11), twap.SqrtPriceX96)
+	diff := new(big.Int).Sub(twap.HarmonicMeanLiquidity, liquidity)
+	assert.Equal(t, true, diff.CmpAbs(big.NewInt(1000)) <= 0)
+
+	twap, err = ComputeTWAP(60,
+		[]*big.Int{big.NewInt(0), big.NewInt(0)},
+		[]*big.Int{new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1)), new(big.Int).Sub(secondsPerLiquidityDelta, big.NewInt(1))},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "1", twap.Price(true).String())
+	diff = new(big.Int).Sub(twap.HarmonicMeanLiquidity, liquidity)
+	assert.Equal(t, true, diff.CmpAbs(big.NewInt(1000)) <= 0)
+
+	_, err = ComputeTWAP(0, nil, nil)
+	assert.NotEqual(t, nil, err)
+	_, err = ComputeTWAP(60, []*big.Int{big.NewInt(0), big.NewInt(0)}, []*big.Int{start, start})
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,230 @@
+package uniswap
+
+import (
+	"math/big"
+
+	"github.com/ackermanx/ethclient"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	UniversalRouterAddr = "0x3fC91A3afd70395Cd496C647d5a6CC9D4B2b7FAD"
+	UniversalRouterAbi  = `[{"inputs":[{"internalType":"bytes","name":"commands","type":"bytes"},{"internalType":"bytes[]","name":"inputs","type":"bytes[]"},{"internalType":"uint256","name":"deadline","type":"uint256"}],"name":"execute","outputs":[],"stateMutability":"payable","type":"function"}]`
+)
+
+// Universal Router commands
+const (
+	CommandV3SwapExactIn       byte = 0x00
+	CommandV3SwapExactOut      byte = 0x01
+	CommandPermit2TransferFrom byte = 0x02
+	CommandSweep               byte = 0x04
+	CommandTransfer            byte = 0x05
+	CommandPayPortion          byte = 0x06
+	CommandV2SwapExactIn       byte = 0x08
+	CommandV2SwapExactOut      byte = 0x09
+	CommandPermit2Permit       byte = 0x0a
+	CommandWrapETH             byte = 0x0b
+	CommandUnwrapWETH          byte = 0x0c
+
+	// CommandFlagAllowRevert lets the execution continue if the command reverts
+	CommandFlagAllowRevert byte = 0x80
+)
+
+var (
+	// RecipientMsgSender and RecipientAddressThis are the Universal Router placeholders for
+	// the transaction sender and the router itself
+	RecipientMsgSender   = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	RecipientAddressThis = common.HexToAddress("0x0000000000000000000000000000000000000002")
+
+	uint160Type, _      = abi.NewType("uint160", "", nil)
+	uint256Type, _      = abi.NewType("uint256", "", nil)
+	boolType, _         = abi.NewType("bool", "", nil)
+	bytesType, _        = abi.NewType("bytes", "", nil)
+	addressSliceType, _ = abi.NewType("address[]", "", nil)
+	permitSingleType, _ = abi.NewType("tuple", "", []abi.ArgumentMarshaling{
+		{Name: "details", Type: "tuple", Components: []abi.ArgumentMarshaling{
+			{Name: "token", Type: "address"},
+			{Name: "amount", Type: "uint160"},
+			{Name: "expiration", Type: "uint48"},
+			{Name: "nonce", Type: "uint48"},
+		}},
+		{Name: "spender", Type: "address"},
+		{Name: "sigDeadline", Type: "uint256"},
+	})
+
+	v3SwapArguments          = abiArguments(Address, uint256Type, uint256Type, bytesType, boolType)
+	v2SwapArguments          = abiArguments(Address, uint256Type, uint256Type, addressSliceType, boolType)
+	tokenRecipientAmountArgs = abiArguments(Address, Address, uint256Type)
+	permit2TransferFromArgs  = abiArguments(Address, Address, uint160Type)
+	permit2PermitArguments   = abiArguments(permitSingleType, bytesType)
+	recipientAmountArguments = abiArguments(Address, uint256Type)
+)
+
+func abiArguments(argTypes ...abi.Type) abi.Arguments {
+	arguments := make(abi.Arguments, len(argTypes))
+	for i, argType := range argTypes {
+		arguments[i] = abi.Argument{Type: argType}
+	}
+	return arguments
+}
+
+// PermitDetails is the Permit2 IAllowanceTransfer.PermitDetails
+type PermitDetails struct {
+	Token      common.Address
+	Amount     *big.Int
+	Expiration *big.Int
+	Nonce      *big.Int
+}
+
+// PermitSingle is the Permit2 IAllowanceTransfer.PermitSingle
+type PermitSingle struct {
+	Details     PermitDetails
+	Spender     common.Address
+	SigDeadline *big.Int
+}
+
+// RoutePlanner encodes Universal Router commands and their inputs for execute
+type RoutePlanner struct {
+	commands []byte
+	inputs   [][]byte
+}
+
+// NewRoutePlanner creates an empty RoutePlanner
+func NewRoutePlanner() *RoutePlanner {
+	return &RoutePlanner{commands: []byte{}, inputs: [][]byte{}}
+}
+
+// Commands returns the encoded commands
+func (p *RoutePlanner) Commands() []byte {
+	return p.commands
+}
+
+// Inputs returns the encoded inputs of commands
+func (p *RoutePlanner) Inputs() [][]byte {
+	return p.inputs
+}
+
+// AddCommand append command with abi encoded input, allowRevert lets the execution continue if the command reverts
+func (p *RoutePlanner) AddCommand(command byte, input []byte, allowRevert bool) *RoutePlanner {
+	if allowRevert {
+		command |= CommandFlagAllowRevert
+	}
+	p.commands = append(p.commands, command)
+	p.inputs = append(p.inputs, input)
+	return p
+}
+
+func (p *RoutePlanner) addCommand(command byte, arguments abi.Arguments, values ...interface{}) error {
+	input, err := arguments.Pack(values...)
+	if err != nil {
+		return errors.Wrapf(err, "pack command 0x%02x", command)
+	}
+	p.AddCommand(command, input, false)
+	return nil
+}
+
+// V3SwapExactIn add V3_SWAP_EXACT_IN command, path is encoded by EncodePath.
+// payerIsUser true pays with the sender's tokens through Permit2, false pays with tokens held by the router
+func (p *RoutePlanner) V3SwapExactIn(recipient common.Address, amountIn, amountOutMin *big.Int, path []byte, payerIsUser bool) error {
+	return p.addCommand(CommandV3SwapExactIn, v3SwapArguments, recipient, amountIn, amountOutMin, path, payerIsUser)
+}
+
+// V3SwapExactOut add V3_SWAP_EXACT_OUT command, path is encoded by EncodePathReversed
+func (p *RoutePlanner) V3SwapExactOut(recipient common.Address, amountOut, amountInMax *big.Int, path []byte, payerIsUser bool) error {
+	return p.addCommand(CommandV3SwapExactOut, v3SwapArguments, recipient, amountOut, amountInMax, path, payerIsUser)
+}
+
+// V2SwapExactIn add V2_SWAP_EXACT_IN command
+func (p *RoutePlanner) V2SwapExactIn(recipient common.Address, amountIn, amountOutMin *big.Int, path []common.Address, payerIsUser bool) error {
+	return p.addCommand(CommandV2SwapExactIn, v2SwapArguments, recipient, amountIn, amountOutMin, path, payerIsUser)
+}
+
+// V2SwapExactOut add V2_SWAP_EXACT_OUT command
+func (p *RoutePlanner) V2SwapExactOut(recipient common.Address, amountOut, amountInMax *big.Int, path []common.Address, payerIsUser bool) error {
+	return p.addCommand(CommandV2SwapExactOut, v2SwapArguments, recipient, amountOut, amountInMax, path, payerIsUser)
+}
+
+// WrapETH add WRAP_ETH command wrapping amountMin of the router's native currency to WETH
+func (p *RoutePlanner) WrapETH(recipient common.Address, amountMin *big.Int) error {
+	return p.addCommand(CommandWrapETH, recipientAmountArguments, recipient, amountMin)
+}
+
+// UnwrapWETH add UNWRAP_WETH command unwrapping all WETH held by the router, reverts below amountMin
+func (p *RoutePlanner) UnwrapWETH(recipient common.Address, amountMin *big.Int) error {
+	return p.addCommand(CommandUnwrapWETH, recipientAmountArguments, recipient, amountMin)
+}
+
+// Permit2Permit add PERMIT2_PERMIT command with the sender's signature of permit
+func (p *RoutePlanner) Permit2Permit(permit PermitSingle, signature []byte) error {
+	return p.addCommand(CommandPermit2Permit, permit2PermitArguments, permit, signature)
+}
+
+// Permit2TransferFrom add PERMIT2_TRANSFER_FROM command transferring amount of token from the sender
+func (p *RoutePlanner) Permit2TransferFrom(token, recipient common.Address, amount *big.Int) error {
+	return p.addCommand(CommandPermit2TransferFrom, permit2TransferFromArgs, token, recipient, amount)
+}
+
+// Sweep add SWEEP command sending all token held by the router to recipient, reverts below amountMin.
+// Use the zero address as token for native currency
+func (p *RoutePlanner) Sweep(token, recipient common.Address, amountMin *big.Int) error {
+	return p.addCommand(CommandSweep, tokenRecipientAmountArgs, token, recipient, amountMin)
+}
+
+// Transfer add TRANSFER command sending value of token held by the router to recipient
+func (p *RoutePlanner) Transfer(token, recipient common.Address, value *big.Int) error {
+	return p.addCommand(CommandTransfer, tokenRecipientAmountArgs, token, recipient, value)
+}
+
+// PayPortion add PAY_PORTION command sending bips basis points of token held by the router to recipient
+func (p *RoutePlanner) PayPortion(token, recipient common.Address, bips *big.Int) error {
+	return p.addCommand(CommandPayPortion, tokenRecipientAmountArgs, token, recipient, bips)
+}
+
+// UniversalRouter builds signed execute transactions of uniswap Universal Router
+type UniversalRouter struct {
+	client  *ethclient.Client
+	Address common.Address
+}
+
+// NewUniversalRouter creates Universal Router transaction builder of router address
+func NewUniversalRouter(client *ethclient.Client, router common.Address) *UniversalRouter {
+	return &UniversalRouter{client: client, Address: router}
+}
+
+// Execute build execute transaction of planned commands, set opts.Value when commands spend native currency
+func (r *UniversalRouter) Execute(privKey string, opts *bind.TransactOpts, planner *RoutePlanner, params SwapParams) (*types.Transaction, error) {
+	if len(planner.commands) == 0 {
+		return nil, errors.New("no commands planned")
+	}
+	opts, err := transactOpts(privKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.client.BuildContractTx(privKey, "execute", UniversalRouterAbi, &r.Address, opts, planner.Commands(), planner.Inputs(), params.deadline())
+}
+
+// SwapExactInV3 build execute transaction swapping amountIn of path[0] to the last token of path through v3 pools,
+// amountOutMin is quotedAmountOut less slippage. The input is paid through Permit2, so permit2 approval is required.
+func (r *UniversalRouter) SwapExactInV3(privKey string, opts *bind.TransactOpts, path []common.Address, fees []int, amountIn, quotedAmountOut *big.Int, params SwapParams) (*types.Transaction, error) {
+	encodedPath, err := EncodePath(path, fees)
+	if err != nil {
+		return nil, err
+	}
+	recipient := params.Recipient
+	if recipient == (common.Address{}) {
+		recipient = RecipientMsgSender
+	}
+	amountOutMin, err := MinimumAmountOut(quotedAmountOut, params.SlippageBps)
+	if err != nil {
+		return nil, err
+	}
+	planner := NewRoutePlanner()
+	if err = planner.V3SwapExactIn(recipient, amountIn, amountOutMin, encodedPath, true); err != nil {
+		return nil, err
+	}
+	return r.Execute(privKey, opts, planner, params)
+}